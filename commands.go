@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"muletinha/diagnose"
+	"muletinha/entity"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"muletinha/process"
+	"muletinha/sigscan"
+	"muletinha/snapshot"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// loadCode abre o código do x2game.dll de um snapshot (.snap) ou do DLL em disco.
+func loadCode(path string) (*sigscan.Module, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".snap") {
+		return sigscan.ReadFile(path)
+	}
+
+	snap, err := snapshot.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := snap.ModuleBase("x2game.dll")
+	if err != nil {
+		return nil, err
+	}
+	return sigscan.ReadModule(snap, base)
+}
+
+// runSigscan: muletinha sigscan <x2game.dll|arquivo.snap> <perfil.json|"padrão AOB">
+//
+// Com um perfil, resolve todas as assinaturas dele e compara com as bases
+// gravadas; com um padrão, lista todas as ocorrências.
+func runSigscan(args []string) int {
+	if len(args) < 2 {
+		fmt.Println(`Uso: muletinha sigscan <x2game.dll|arquivo.snap> <perfil.json|"8B 0D ?? ?? ?? ??">`)
+		return 2
+	}
+
+	mod, err := loadCode(args[0])
+	if err != nil {
+		fmt.Printf("Erro ao ler %s: %v\n", args[0], err)
+		return 1
+	}
+	for _, s := range mod.Sections {
+		fmt.Printf("[SIGSCAN] Seção %-8s RVA %08X (%d KB)\n", s.Name, s.RVA, len(s.Data)/1024)
+	}
+
+	if !strings.HasSuffix(strings.ToLower(args[1]), ".json") {
+		p, err := sigscan.ParsePattern(args[1])
+		if err != nil {
+			fmt.Printf("Padrão inválido: %v\n", err)
+			return 2
+		}
+		matches := mod.Find(p)
+		for _, m := range matches {
+			fmt.Printf("  %s+%08X\n", m.Section.Name, m.RVA())
+		}
+		fmt.Printf("%d ocorrência(s) de %s\n", len(matches), p)
+		return 0
+	}
+
+	prof, err := offsets.LoadProfile(args[1])
+	if err != nil {
+		fmt.Printf("Erro ao carregar perfil: %v\n", err)
+		return 1
+	}
+	if len(prof.Signatures) == 0 {
+		fmt.Printf("Perfil %s não declara assinaturas\n", prof.Name)
+		return 0
+	}
+
+	failed := 0
+	for _, c := range prof.Chains.All() {
+		sig, ok := prof.Signatures[c.Name]
+		if !ok {
+			continue
+		}
+		rva, err := sig.Resolve(mod)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("  FALHA %-12s %v\n", c.Name, err)
+		case c.Base != 0 && c.Base != rva:
+			fmt.Printf("  OK    %-12s 0x%X (perfil tem 0x%X)\n", c.Name, rva, c.Base)
+		default:
+			fmt.Printf("  OK    %-12s 0x%X\n", c.Name, rva)
+		}
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// attach abre o cliente em execução ou, com filename, um snapshot. Retorna o
+// backend, a base do x2game.dll e o caminho do DLL (vazio no snapshot).
+func attach(filename string) (memory.ProcessMemory, uintptr, string, error) {
+	if filename != "" {
+		snap, err := snapshot.Open(filename)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		base, err := snap.ModuleBase("x2game.dll")
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return snap, base, "", nil
+	}
+
+	pid, err := process.FindProcess("archeage.exe")
+	if err != nil || pid == 0 {
+		return nil, 0, "", errors.New("ArcheAge não encontrado")
+	}
+	mem, err := memory.OpenProcess(pid)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	mod, err := process.GetModule(pid, "x2game.dll")
+	if err != nil {
+		mem.Close()
+		return nil, 0, "", errors.New("x2game.dll não encontrado")
+	}
+	return mem, mod.Base, mod.Path, nil
+}
+
+// runDiagnose: muletinha diagnose [-p perfil.json] [arquivo.snap]
+//
+// Resolve todas as cadeias do perfil ativo e valida os valores. Depois de um
+// patch, -p força o perfil antigo para ver quais offsets quebraram.
+func runDiagnose(args []string) int {
+	fs := flag.NewFlagSet("diagnose", flag.ExitOnError)
+	profileFile := fs.String("p", "", "perfil de offsets a usar em vez do selecionado pela build")
+	fs.Parse(args)
+
+	mem, x2game, path, err := attach(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return 1
+	}
+	defer mem.Close()
+
+	build := offsets.DetectBuild(mem, x2game, path)
+
+	var prof *offsets.Profile
+	if *profileFile != "" {
+		prof, err = offsets.LoadProfile(*profileFile)
+	} else {
+		var rep *diagnose.Report
+		prof, rep, err = diagnose.SelectProfile(mem, x2game, build)
+		if err != nil && rep != nil {
+			// Por que os offsets padrão não valem para esta build
+			rep.Print(os.Stdout)
+			fmt.Println()
+		}
+	}
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		if errors.Is(err, offsets.ErrUnsupportedBuild) {
+			fmt.Println("Use -p <perfil.json> para testar um perfil existente contra esta build")
+		}
+		return 1
+	}
+
+	if len(prof.Signatures) > 0 {
+		mod, err := sigscan.ReadModule(mem, x2game)
+		if err == nil {
+			err = prof.ApplySignatures(mod)
+		}
+		if err != nil {
+			fmt.Printf("Assinaturas: %v\n", err)
+		}
+	}
+	offsets.SetActive(prof)
+
+	rep := diagnose.Run(mem, x2game, prof, build)
+	rep.Print(os.Stdout)
+	if rep.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runBench: muletinha bench [-runs N] [-workers N] <arquivo.snap>
+//
+// Mede o scanner de entidades contra um snapshot, com 1 worker e com N.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	runs := fs.Int("runs", 5, "repetições por configuração")
+	workers := fs.Int("workers", runtime.NumCPU(), "workers da configuração paralela")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fmt.Println("Uso: muletinha bench [-runs N] [-workers N] <arquivo.snap>")
+		return 2
+	}
+
+	mem, x2game, _, err := attach(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return 1
+	}
+	defer mem.Close()
+
+	build := offsets.DetectBuild(mem, x2game, "")
+	prof, _, err := diagnose.SelectProfile(mem, x2game, build)
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return 1
+	}
+	offsets.SetActive(prof)
+	if m, err := memory.LoadAddressMap(mem); err == nil {
+		memory.SetAddressMap(m)
+	}
+	known, err := entity.LoadVTableSet(entity.VTableFile(build))
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return 1
+	}
+	filter, err := entity.NewVTableFilter(mem, x2game, prof, known)
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return 1
+	}
+	entity.SetVTableFilter(filter)
+
+	// Sem limite de distância para medir o trabalho todo
+	player := entity.GetLocalPlayer(mem, x2game)
+
+	var base time.Duration
+	for _, w := range []int{1, *workers} {
+		var best, total time.Duration
+		var prog entity.ScanProgress
+		for i := 0; i < *runs; i++ {
+			var err error
+			_, prog, err = entity.Scan(context.Background(), mem, player, entity.ScanOptions{
+				MaxDistance: math.MaxFloat32,
+				Workers:     w,
+			})
+			if err != nil {
+				fmt.Printf("Erro: %v\n", err)
+				return 1
+			}
+			total += prog.Elapsed
+			if best == 0 || prog.Elapsed < best {
+				best = prog.Elapsed
+			}
+		}
+
+		avg := total / time.Duration(*runs)
+		mbps := float64(prog.TotalBytes) / (1 << 20) / avg.Seconds()
+		line := fmt.Sprintf("%2d worker(s): média %v, melhor %v, %d MB, %.0f MB/s, %d entidades",
+			w, avg.Round(time.Microsecond), best.Round(time.Microsecond), prog.TotalBytes>>20, mbps, prog.Found)
+		if w == 1 {
+			base = avg
+		} else {
+			line += fmt.Sprintf(", %.2fx", float64(base)/float64(avg))
+		}
+		fmt.Println(line)
+	}
+	return 0
+}
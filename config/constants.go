@@ -1,6 +1,9 @@
 package config
 
-import "time"
+import (
+	"muletinha/memory"
+	"time"
+)
 
 // Memory offsets
 const (
@@ -68,18 +71,101 @@ const (
 	OFF_BUFF_FREEZE_FINAL uint32 = 0x384
 )
 
+// Build do x2game.dll para a qual os offsets acima foram achados (valores do
+// header PE, `muletinha diagnose` mostra os da build atual). Com eles o perfil
+// padrão é criado sem testar nada; zerados, os offsets são testados contra o
+// cliente no attach e viram o perfil da build em que passam.
+const (
+	X2GAME_TIMESTAMP     uint32 = 0 // IMAGE_FILE_HEADER.TimeDateStamp
+	X2GAME_SIZE_OF_IMAGE uint32 = 0 // IMAGE_OPTIONAL_HEADER.SizeOfImage
+)
+
 // Screen settings
 const (
     SCREEN_WIDTH  = 1920
     SCREEN_HEIGHT = 1080
 
     RADAR_RADIUS  = 280
-    RADAR_RANGE   = 1000.0
+
+    // Alcance inicial do radar (metros até a borda) e limites do zoom
+    RADAR_RANGE     = 1000.0
+    RADAR_MIN_RANGE = 50.0
+    RADAR_MAX_RANGE = 5000.0
+
+    // Alcance mínimo do scan; com o radar afastado ou deslocado o scan vai
+    // até onde o radar mostra
     SCAN_RANGE    = 1000.0
+
+    // Deslocamento do player entre dois frames que conta como teleporte:
+    // limpa o tracker e refaz o scan
+    TELEPORT_DISTANCE = 200.0
 )
 
+// Fontes TTF/TTC para nomes fora do ASCII, em ordem de preferência; a
+// primeira que tiver todos os glifos do texto é usada
+var UI_FONTS = []string{
+	`C:\Windows\Fonts\segoeui.ttf`,
+	`C:\Windows\Fonts\malgun.ttf`,
+	`C:\Windows\Fonts\msgothic.ttc`,
+	`C:\Windows\Fonts\simsun.ttc`,
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+}
+
 // Key spam settings
 const (
 	KEY_SPAM_COUNT    = 5
 	KEY_SPAM_INTERVAL = 15 * time.Millisecond
-)
\ No newline at end of file
+)
+
+// Pointer chains. Module chains start at the module base; chains without a
+// module start at an entity address.
+var (
+	ChainLocalPlayer = memory.PointerChain{
+		Name: "localplayer", Module: "x2game.dll",
+		Base: PTR_LOCALPLAYER, Offsets: []uint32{PTR_ENTITY},
+	}
+
+	// Retorna o objeto de stats; current/max em OFF_MANA_CURRENT/OFF_MANA_MAX
+	ChainMana = memory.PointerChain{
+		Name: "mana", Module: "x2game.dll",
+		Base:    PTR_MANA_BASE,
+		Offsets: []uint32{OFF_MANA_PTR1, OFF_MANA_PTR2, OFF_MANA_PTR3, OFF_MANA_PTR4, OFF_MANA_PTR5, OFF_MANA_PTR6},
+	}
+
+	// Lista de debuffs (count em OFF_DEBUFF_COUNT) e de buffs (count em BUFF_COUNT_OFF)
+	ChainDebuffList = memory.PointerChain{
+		Name: "debuff_list", Module: "x2game.dll",
+		Base: PTR_LOCALPLAYER, Offsets: []uint32{PTR_ENTITY, OFF_ENTITY_BASE, OFF_DEBUFF_PTR},
+		Check: memory.CheckValidPtr,
+	}
+
+	ChainBuffFreeze = memory.PointerChain{
+		Name: "buff_freeze", Module: "x2game.dll",
+		Base:    PTR_BUFF_FREEZE,
+		Offsets: []uint32{OFF_BUFF_FREEZE_PTR1, OFF_BUFF_FREEZE_PTR2, OFF_BUFF_FREEZE_PTR3},
+		Final:   OFF_BUFF_FREEZE_FINAL,
+	}
+
+	ChainMount = memory.PointerChain{
+		Name: "mount", Module: "x2game.dll",
+		Base: PTR_MOUNT_BASE, Offsets: []uint32{OFF_MOUNT_PTR1, OFF_MOUNT_PTR2},
+	}
+
+	// Relativas ao endereço da entidade
+	ChainMaxHP = memory.PointerChain{
+		Name:    "max_hp",
+		Base:    OFF_ENTITY_BASE,
+		Offsets: []uint32{OFF_TO_ESI, OFF_TO_STATS},
+		Final:   OFF_MAXHP,
+		Check:   memory.CheckValidPtr,
+	}
+
+	ChainEntityName = memory.PointerChain{
+		Name:    "entity_name",
+		Base:    OFF_NAME_PTR1,
+		Offsets: []uint32{OFF_NAME_PTR2},
+		Check:   memory.CheckValidPtr,
+	}
+)
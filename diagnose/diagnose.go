@@ -0,0 +1,248 @@
+package diagnose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"muletinha/entity"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"strings"
+)
+
+// Listas com mais entradas que isso são lixo (offset de count errado)
+const maxListCount = 50
+
+type Status int
+
+const (
+	Pass Status = iota
+	Fail
+	Skip
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pass:
+		return "OK"
+	case Fail:
+		return "FALHA"
+	}
+	return "-"
+}
+
+// Result is one row of the report: a chain or a struct field.
+type Result struct {
+	Group  string
+	Name   string
+	Status Status
+	Detail string
+}
+
+type Report struct {
+	Profile string
+	Build   offsets.BuildID
+	Results []Result
+}
+
+func (r *Report) add(group, name string, st Status, format string, args ...any) {
+	r.Results = append(r.Results, Result{Group: group, Name: name, Status: st, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *Report) check(group, name string, ok bool, format string, args ...any) {
+	st := Fail
+	if ok {
+		st = Pass
+	}
+	r.add(group, name, st, format, args...)
+}
+
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == Fail {
+			n++
+		}
+	}
+	return n
+}
+
+// Print writes the pass/fail table grouped by chain/struct.
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Perfil: %s\nBuild:  %s\n\n", r.Profile, r.Build)
+	fmt.Fprintf(w, "%-12s %-16s %-6s %s\n", "GRUPO", "CAMPO", "STATUS", "DETALHE")
+	fmt.Fprintln(w, strings.Repeat("-", 72))
+
+	last := ""
+	for _, res := range r.Results {
+		group := res.Group
+		if group == last {
+			group = ""
+		}
+		last = res.Group
+		fmt.Fprintf(w, "%-12s %-16s %-6s %s\n", group, res.Name, res.Status, res.Detail)
+	}
+
+	fmt.Fprintln(w, strings.Repeat("-", 72))
+	if n := r.Failed(); n > 0 {
+		fmt.Fprintf(w, "%d verificação(ões) falharam\n", n)
+	} else {
+		fmt.Fprintln(w, "Tudo OK")
+	}
+}
+
+// Run resolves every chain of p against pm and validates the values they
+// lead to. x2game is the module base of x2game.dll.
+func Run(pm memory.ProcessMemory, x2game uintptr, p *offsets.Profile, build offsets.BuildID) *Report {
+	r := &Report{Profile: fmt.Sprintf("%s (%s)", p.Name, p.File), Build: build}
+
+	player, ok := r.chain(pm, x2game, &p.Chains.LocalPlayer, 0)
+	if ok {
+		r.localPlayer(pm, p, player)
+	}
+
+	if stats, ok := r.chain(pm, x2game, &p.Chains.Mana, 0); ok {
+		cur := memory.ReadU32(pm, stats+uintptr(p.Mana.Current))
+		max := memory.ReadU32(pm, stats+uintptr(p.Mana.Max))
+		r.check("mana", "max", max > 0, "%d", max)
+		r.check("mana", "current", cur <= max, "%d <= %d", cur, max)
+	}
+
+	if list, ok := r.chain(pm, x2game, &p.Chains.DebuffList, 0); ok {
+		d := p.Debuff
+		r.list(pm, "debuff", list, d.Count, d.Array, d.Size, d.Duration, d.TimeLeft)
+		b := p.Buff
+		r.list(pm, "buff", list, b.Count, b.Array, b.Size, b.Duration, b.TimeLeft)
+	}
+
+	if addr, ok := r.chain(pm, x2game, &p.Chains.BuffFreeze, 0); ok {
+		var buf [4]byte
+		err := memory.ReadMemoryBytes(pm, addr, buf[:])
+		r.check("buff_freeze", "valor", err == nil, "%08X = %d", addr, memory.BytesToUint32(buf[:]))
+	}
+
+	if mount, ok := r.chain(pm, x2game, &p.Chains.Mount, 0); ok {
+		r.check("mount", "endereço", memory.IsValidPtr(uint32(mount)), "%08X", mount)
+	}
+
+	if target, ok := r.chain(pm, x2game, &p.Chains.Target, 0); ok {
+		t := p.Target
+		hp := memory.ReadU32(pm, target+uintptr(t.HP))
+		maxHP := memory.ReadU32(pm, target+uintptr(t.MaxHP))
+		mp := memory.ReadU32(pm, target+uintptr(t.Mana))
+		maxMP := memory.ReadU32(pm, target+uintptr(t.MaxMana))
+		r.check("target", "hp", maxHP > 0 && hp <= maxHP, "%d / %d", hp, maxHP)
+		r.check("target", "mana", mp <= maxMP, "%d / %d", mp, maxMP)
+	}
+
+	return r
+}
+
+// SelectProfile picks the profile for build like offsets.Select. A build with
+// no profile gets the compiled-in defaults if every check of Run passes with
+// them against the client: that is the build they were written for, and they
+// are saved as its profile. rep is that check, nil when it did not run.
+func SelectProfile(pm memory.ProcessMemory, x2game uintptr, build offsets.BuildID) (*offsets.Profile, *Report, error) {
+	prof, err := offsets.Select(offsets.ProfileDir, build)
+	if !errors.Is(err, offsets.ErrUnsupportedBuild) {
+		return prof, nil, err
+	}
+
+	def := offsets.Default()
+	def.File = "padrão"
+	rep := Run(pm, x2game, def, build)
+	if rep.Failed() > 0 {
+		return nil, rep, err
+	}
+
+	prof, err = offsets.SaveDefault(offsets.ProfileDir, build)
+	return prof, rep, err
+}
+
+// chain resolves c and records the result. Relative chains start at rel;
+// they are skipped when rel is 0 (their owner failed).
+func (r *Report) chain(pm memory.ProcessMemory, x2game uintptr, c *memory.PointerChain, rel uintptr) (uintptr, bool) {
+	base := x2game
+	if c.Module == "" {
+		base = rel
+	}
+
+	switch {
+	case offsets.OptionalChain(c.Name) && c.Base == 0:
+		r.add(c.Name, "cadeia", Skip, "não configurada no perfil")
+		return 0, false
+	case base == 0:
+		r.add(c.Name, "cadeia", Skip, "sem objeto de origem")
+		return 0, false
+	}
+
+	addr, err := c.Resolve(pm, base)
+	if err != nil {
+		// Sem montaria o ponteiro é nulo; não é offset quebrado
+		if c.Name == "mount" && errors.Is(err, memory.ErrNullPointer) {
+			r.add(c.Name, "cadeia", Skip, "%v (sem montaria?)", err)
+			return 0, false
+		}
+		r.add(c.Name, "cadeia", Fail, "%v", err)
+		return 0, false
+	}
+
+	r.add(c.Name, "cadeia", Pass, "%08X (%d hops)", addr, c.Hops())
+	return addr, true
+}
+
+func (r *Report) localPlayer(pm memory.ProcessMemory, p *offsets.Profile, addr uintptr) {
+	e := p.Entity
+	x := memory.ReadF32(pm, addr+uintptr(e.PosX))
+	y := memory.ReadF32(pm, addr+uintptr(e.PosY))
+	z := memory.ReadF32(pm, addr+uintptr(e.PosZ))
+	r.check("localplayer", "pos_x", memory.IsValidCoord(x), "%.1f", x)
+	r.check("localplayer", "pos_y", memory.IsValidCoord(y), "%.1f", y)
+	r.check("localplayer", "pos_z", memory.IsValidCoord(z), "%.1f", z)
+
+	hp := memory.ReadU32(pm, addr+uintptr(e.HP))
+	r.check("localplayer", "hp", hp > 0, "%d", hp)
+
+	if a, ok := r.chain(pm, 0, &p.Chains.MaxHP, addr); ok {
+		maxHP := memory.ReadU32(pm, a)
+		r.check("max_hp", "valor", maxHP > 0, "%d", maxHP)
+		r.check("max_hp", "hp <= max_hp", hp <= maxHP, "%d <= %d", hp, maxHP)
+	}
+
+	if a, ok := r.chain(pm, 0, &p.Chains.EntityName, addr); ok {
+		name := memory.ReadStringEncoded(pm, a, 32, p.Encoding)
+		r.check("entity_name", "nome", entity.IsValidEntityName(name), "%q", name)
+	}
+}
+
+// list validates a buff/debuff list: count in range and every entry with
+// TimeLeft <= Duration.
+func (r *Report) list(pm memory.ProcessMemory, group string, list uintptr, countOff, arrayOff, size, durOff, leftOff uint32) {
+	count := memory.ReadU32(pm, list+uintptr(countOff))
+	if count > maxListCount {
+		r.check(group, "count", false, "%d > %d", count, maxListCount)
+		return
+	}
+	r.check(group, "count", true, "%d", count)
+	if count == 0 {
+		r.add(group, "entradas", Skip, "lista vazia")
+		return
+	}
+
+	buf := make([]byte, count*size)
+	if err := memory.ReadMemoryBytes(pm, list+uintptr(arrayOff), buf); err != nil {
+		r.check(group, "entradas", false, "%v", err)
+		return
+	}
+
+	bad := 0
+	for i := uint32(0); i < count; i++ {
+		entry := buf[i*size:]
+		dur := memory.BytesToUint32(entry[durOff:])
+		left := memory.BytesToUint32(entry[leftOff:])
+		if left > dur {
+			bad++
+		}
+	}
+	r.check(group, "entradas", bad == 0, "%d/%d com time_left <= duration", count-uint32(bad), count)
+}
@@ -0,0 +1,162 @@
+package diagnose
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testModule = 0x00400000
+
+// fakeClient monta as cadeias do perfil padrão numa memory.Image, com os
+// objetos a partir de 0x20000000.
+type fakeClient struct {
+	img  *memory.Image
+	next uintptr
+}
+
+func (f *fakeClient) alloc() uintptr {
+	addr := f.next
+	f.img.Map(addr, make([]byte, 0x10000))
+	f.next += 0x10000
+	return addr
+}
+
+func (f *fakeClient) putU32(addr uintptr, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	if f.img.WriteBytes(addr, b[:]) != nil {
+		f.img.Map(addr, b[:])
+	}
+}
+
+// link makes c, walked from base, resolve to target. Pointers already written
+// are followed, so chains with a common prefix share their objects.
+func (f *fakeClient) link(c memory.PointerChain, base, target uintptr) {
+	addr := base + c.Base
+	for _, off := range c.Offsets {
+		obj := uintptr(memory.ReadU32(f.img, addr))
+		if obj == 0 {
+			obj = f.alloc()
+			f.putU32(addr, uint32(obj))
+		}
+		addr = obj + uintptr(off)
+	}
+	f.putU32(addr, uint32(target-uintptr(c.Final)))
+}
+
+// newFakeClient builds a logged-in client for the default profile.
+func newFakeClient() *fakeClient {
+	f := &fakeClient{img: memory.NewImage(), next: 0x20000000}
+	p := offsets.Default()
+
+	ent := f.alloc()
+	f.link(p.Chains.LocalPlayer, testModule, ent)
+	f.putU32(ent+uintptr(p.Entity.PosX), math.Float32bits(1000))
+	f.putU32(ent+uintptr(p.Entity.PosY), math.Float32bits(2000))
+	f.putU32(ent+uintptr(p.Entity.PosZ), math.Float32bits(100))
+	f.putU32(ent+uintptr(p.Entity.HP), 1500)
+
+	name := f.alloc()
+	f.img.WriteBytes(name, []byte("Fulano\x00"))
+	f.link(p.Chains.EntityName, ent, name)
+
+	maxHP := f.alloc() + uintptr(p.Chains.MaxHP.Final)
+	f.putU32(maxHP, 2000)
+	f.link(p.Chains.MaxHP, ent, maxHP)
+
+	stats := f.alloc()
+	f.putU32(stats+uintptr(p.Mana.Current), 700)
+	f.putU32(stats+uintptr(p.Mana.Max), 900)
+	f.link(p.Chains.Mana, testModule, stats)
+
+	f.link(p.Chains.DebuffList, testModule, f.alloc())
+	f.link(p.Chains.BuffFreeze, testModule, f.alloc()+uintptr(p.Chains.BuffFreeze.Final))
+
+	// Sem montaria
+	f.putU32(testModule+p.Chains.Mount.Base, 0)
+	return f
+}
+
+// inTempDir runs the test with profiles/ under a fresh directory.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSelectProfileAdoptsDefaults(t *testing.T) {
+	inTempDir(t)
+	build := offsets.BuildID{TimeDateStamp: 0x5F000000, SizeOfImage: 0x02000000}
+	f := newFakeClient()
+
+	prof, rep, err := SelectProfile(f.img, testModule, build)
+	if err != nil {
+		if rep != nil {
+			rep.Print(os.Stdout)
+		}
+		t.Fatalf("SelectProfile: %v", err)
+	}
+	if rep == nil || rep.Failed() != 0 {
+		t.Fatalf("report = %+v, want the passing check of the defaults", rep)
+	}
+	want := filepath.Join(offsets.ProfileDir, "default-5F000000.json")
+	if prof.File != want || prof.Build != build {
+		t.Errorf("profile %s for %s, want %s for %s", prof.File, prof.Build, want, build)
+	}
+
+	// A próxima vez acha o perfil salvo pela build, sem testar de novo
+	prof, rep, err = SelectProfile(memory.NewImage(), testModule, build)
+	if err != nil || rep != nil {
+		t.Fatalf("second SelectProfile = %v, report %v", err, rep)
+	}
+	if prof.File != want {
+		t.Errorf("second SelectProfile picked %s, want %s", prof.File, want)
+	}
+}
+
+func TestSelectProfileRejectsOtherBuild(t *testing.T) {
+	inTempDir(t)
+	build := offsets.BuildID{TimeDateStamp: 0x60000000, SizeOfImage: 0x02100000}
+
+	tests := []struct {
+		name string
+		img  *memory.Image
+	}{
+		{name: "offsets don't resolve", img: memory.NewImage()},
+		{
+			name: "not logged in",
+			img: func() *memory.Image {
+				f := newFakeClient()
+				f.putU32(testModule+offsets.Default().Chains.LocalPlayer.Base, 0)
+				return f.img
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prof, rep, err := SelectProfile(tt.img, testModule, build)
+			if !errors.Is(err, offsets.ErrUnsupportedBuild) {
+				t.Fatalf("SelectProfile = %v, %v; want ErrUnsupportedBuild", prof, err)
+			}
+			if rep == nil || rep.Failed() == 0 {
+				t.Errorf("report = %+v, want failed checks", rep)
+			}
+			if files, _ := filepath.Glob(filepath.Join(offsets.ProfileDir, "*.json")); len(files) > 0 {
+				t.Errorf("profiles written: %v", files)
+			}
+		})
+	}
+}
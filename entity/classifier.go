@@ -0,0 +1,248 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"muletinha/memory"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// ClassifierFile holds the classification rules, next to rules.json.
+const ClassifierFile = "entity_classes.json"
+
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindPlayer
+	KindNPC
+	KindMount
+	KindPet
+	KindGatherable
+	KindObject
+)
+
+var kindNames = []string{"unknown", "player", "npc", "mount", "pet", "gatherable", "object"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+func (k Kind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+func (k *Kind) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for i, name := range kindNames {
+		if strings.EqualFold(s, name) {
+			*k = Kind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("tipo de entidade desconhecido %q", s)
+}
+
+// Classifier decides what kind of thing an entity is.
+type Classifier interface {
+	Classify(e Entity) Kind
+}
+
+// VTableRange is a range of vtable RVAs in x2game.dll, written as
+// "0x1100000-0x11FFFFF" or a single "0x1100234".
+type VTableRange struct {
+	Min, Max uint32
+}
+
+func (r VTableRange) MarshalJSON() ([]byte, error) {
+	if r.Min == r.Max {
+		return json.Marshal(fmt.Sprintf("0x%X", r.Min))
+	}
+	return json.Marshal(fmt.Sprintf("0x%X-0x%X", r.Min, r.Max))
+}
+
+func (r *VTableRange) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	min, err := memory.ParseHex(lo)
+	if err != nil {
+		return fmt.Errorf("vtable %q: %v", s, err)
+	}
+	max := min
+	if isRange {
+		if max, err = memory.ParseHex(hi); err != nil {
+			return fmt.Errorf("vtable %q: %v", s, err)
+		}
+	}
+	r.Min, r.Max = min, max
+	return nil
+}
+
+// Rule matches when every criterion it sets matches. Names are exact
+// (case-insensitive), patterns are regular expressions on the name.
+type Rule struct {
+	Kind     Kind          `json:"kind"`
+	Names    []string      `json:"names,omitempty"`
+	Patterns []string      `json:"patterns,omitempty"`
+	VTables  []VTableRange `json:"vtables,omitempty"`
+	MinHP    uint32        `json:"min_hp,omitempty"`
+	MaxHP    uint32        `json:"max_hp,omitempty"`
+	HasSpace *bool         `json:"has_space,omitempty"`
+
+	names    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func (r *Rule) compile() error {
+	r.names = make(map[string]bool, len(r.Names))
+	for _, n := range r.Names {
+		r.names[strings.ToLower(n)] = true
+	}
+	r.patterns = r.patterns[:0]
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return fmt.Errorf("padrão %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return nil
+}
+
+func (r *Rule) Match(e Entity) bool {
+	if len(r.names) > 0 || len(r.patterns) > 0 {
+		ok := r.names[strings.ToLower(e.Name)]
+		for _, re := range r.patterns {
+			if ok {
+				break
+			}
+			ok = re.MatchString(e.Name)
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	// Regras usam RVAs no x2game.dll; sem módulo conhecido o critério é
+	// ignorado e valem os outros
+	if f := vtableFilter.Load(); len(r.VTables) > 0 && f != nil {
+		rva, ok := f.RVA(e.VTable)
+		if !ok {
+			return false
+		}
+		ok = false
+		for _, v := range r.VTables {
+			if rva >= v.Min && rva <= v.Max {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if r.MinHP != 0 && e.MaxHP < r.MinHP {
+		return false
+	}
+	if r.MaxHP != 0 && e.MaxHP > r.MaxHP {
+		return false
+	}
+	if r.HasSpace != nil && strings.Contains(e.Name, " ") != *r.HasSpace {
+		return false
+	}
+	return true
+}
+
+// RuleClassifier applies rules in order; the first match wins.
+type RuleClassifier struct {
+	Rules   []Rule `json:"rules"`
+	Default Kind   `json:"default"`
+}
+
+func (c *RuleClassifier) Classify(e Entity) Kind {
+	for i := range c.Rules {
+		if c.Rules[i].Match(e) {
+			return c.Rules[i].Kind
+		}
+	}
+	return c.Default
+}
+
+// DefaultClassifier reproduces the original heuristic: prefab_/object_ are
+// objects, names with a space are NPCs, the rest are players.
+func DefaultClassifier() *RuleClassifier {
+	yes := true
+	c := &RuleClassifier{
+		Rules: []Rule{
+			{Kind: KindObject, Patterns: []string{"^prefab_", "^object_"}},
+			{Kind: KindNPC, HasSpace: &yes},
+		},
+		Default: KindPlayer,
+	}
+	for i := range c.Rules {
+		c.Rules[i].compile()
+	}
+	return c
+}
+
+// LoadClassifier reads the rules from filename, creating it with the defaults
+// when missing.
+func LoadClassifier(filename string) (*RuleClassifier, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		c := DefaultClassifier()
+		out, _ := json.MarshalIndent(c, "", "  ")
+		os.WriteFile(filename, out, 0644)
+		fmt.Printf("[CLASS] Criado arquivo %s com as regras padrão\n", filename)
+		return c, nil
+	}
+
+	var c RuleClassifier
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	for i := range c.Rules {
+		if err := c.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: regra %d: %v", filename, i, err)
+		}
+	}
+
+	fmt.Printf("[CLASS] Carregadas %d regras de %s\n", len(c.Rules), filename)
+	return &c, nil
+}
+
+var classifier atomic.Pointer[Classifier]
+
+func SetClassifier(c Classifier) {
+	classifier.Store(&c)
+}
+
+func activeClassifier() Classifier {
+	if c := classifier.Load(); c != nil {
+		return *c
+	}
+	return defaultClassifier
+}
+
+var defaultClassifier Classifier = DefaultClassifier()
+
+// Classify sets Kind and the Is* flags from the active classifier.
+func Classify(e *Entity) {
+	e.Kind = activeClassifier().Classify(*e)
+	e.IsPlayer = e.Kind == KindPlayer
+	e.IsNPC = e.Kind == KindNPC
+	e.IsMount = e.Kind == KindMount
+}
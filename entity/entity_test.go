@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"encoding/binary"
+	"math"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"testing"
+)
+
+const testModule = 0x00400000
+
+// fakeMem monta uma imagem de memória com as cadeias do perfil, alocando os
+// objetos intermediários a partir de 0x20000000 (faixa aceita por IsValidPtr).
+type fakeMem struct {
+	img  *memory.Image
+	next uintptr
+}
+
+func newFakeMem() *fakeMem {
+	return &fakeMem{img: memory.NewImage(), next: 0x20000000}
+}
+
+func (f *fakeMem) alloc(size uint32) uintptr {
+	addr := f.next
+	f.img.Map(addr, make([]byte, size))
+	f.next += (uintptr(size) + 0xFFFF) &^ 0xFFFF
+	return addr
+}
+
+// put writes b at addr, mapping it on its own when nothing covers addr.
+func (f *fakeMem) put(addr uintptr, b []byte) {
+	if f.img.WriteBytes(addr, b) != nil {
+		f.img.Map(addr, append([]byte(nil), b...))
+	}
+}
+
+func (f *fakeMem) putU32(addr uintptr, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	f.put(addr, b[:])
+}
+
+func (f *fakeMem) putF32(addr uintptr, v float32) {
+	f.putU32(addr, math.Float32bits(v))
+}
+
+// link makes c, walked from base, resolve to target.
+func (f *fakeMem) link(c memory.PointerChain, base, target uintptr) {
+	addr := base + c.Base
+	for _, off := range c.Offsets {
+		obj := f.alloc(off + 4)
+		f.putU32(addr, uint32(obj))
+		addr = obj + uintptr(off)
+	}
+	f.putU32(addr, uint32(target-uintptr(c.Final)))
+}
+
+type playerSpec struct {
+	name       string
+	x, y, z    float32
+	hp, maxHP  uint32
+	mana, maxM uint32
+}
+
+// addPlayer builds the local player for p and returns its address.
+func (f *fakeMem) addPlayer(p *offsets.Profile, s playerSpec) uintptr {
+	ent := f.alloc(0x1000)
+	f.link(p.Chains.LocalPlayer, testModule, ent)
+
+	f.putF32(ent+uintptr(p.Entity.PosX), s.x)
+	f.putF32(ent+uintptr(p.Entity.PosY), s.y)
+	f.putF32(ent+uintptr(p.Entity.PosZ), s.z)
+	f.putU32(ent+uintptr(p.Entity.HP), s.hp)
+
+	name := f.alloc(64)
+	f.put(name, []byte(s.name+"\x00"))
+	f.link(p.Chains.EntityName, ent, name)
+
+	maxHP := f.alloc(p.Chains.MaxHP.Final+4) + uintptr(p.Chains.MaxHP.Final)
+	f.putU32(maxHP, s.maxHP)
+	f.link(p.Chains.MaxHP, ent, maxHP)
+
+	stats := f.alloc(0x400)
+	f.putU32(stats+uintptr(p.Mana.Current), s.mana)
+	f.putU32(stats+uintptr(p.Mana.Max), s.maxM)
+	f.link(p.Chains.Mana, testModule, stats)
+
+	return ent
+}
+
+func TestGetLocalPlayer(t *testing.T) {
+	prof := offsets.Default()
+	spec := playerSpec{name: "Fulano", x: 100.5, y: -200.25, z: 30, hp: 1500, maxHP: 2000, mana: 700, maxM: 900}
+
+	tests := []struct {
+		name    string
+		profile *offsets.Profile
+		build   func(f *fakeMem) uintptr
+		want    Entity
+	}{
+		{
+			name:    "full chain",
+			profile: prof,
+			build:   func(f *fakeMem) uintptr { return f.addPlayer(prof, spec) },
+			want:    Entity{Name: "Fulano", PosX: 100.5, PosY: -200.25, PosZ: 30, HP: 1500, MaxHP: 2000, MP: 700, MaxMP: 900},
+		},
+		{
+			name:    "utf8 name",
+			profile: prof,
+			build: func(f *fakeMem) uintptr {
+				s := spec
+				s.name = "Ação"
+				return f.addPlayer(prof, s)
+			},
+			want: Entity{Name: "Ação", PosX: 100.5, PosY: -200.25, PosZ: 30, HP: 1500, MaxHP: 2000, MP: 700, MaxMP: 900},
+		},
+		{
+			name:    "null localplayer",
+			profile: prof,
+			build: func(f *fakeMem) uintptr {
+				f.putU32(testModule+prof.Chains.LocalPlayer.Base, 0)
+				return 0
+			},
+		},
+		{
+			name:  "no active profile",
+			build: func(f *fakeMem) uintptr { return f.addPlayer(prof, spec) },
+		},
+	}
+
+	defer offsets.SetActive(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeMem()
+			addr := tt.build(f)
+			offsets.SetActive(tt.profile)
+
+			got := GetLocalPlayer(f.img, testModule)
+			if tt.want.Name != "" {
+				tt.want.Address = uint32(addr)
+			}
+			if got != tt.want {
+				t.Errorf("GetLocalPlayer =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// RelationsFile holds the friend/party/guild/enemy name lists.
+const RelationsFile = "relations.json"
+
+type Relationship int
+
+const (
+	RelNeutral Relationship = iota
+	RelFriend
+	RelParty
+	RelGuild
+	RelEnemy
+)
+
+func (r Relationship) String() string {
+	switch r {
+	case RelFriend:
+		return "friend"
+	case RelParty:
+		return "party"
+	case RelGuild:
+		return "guild"
+	case RelEnemy:
+		return "enemy"
+	}
+	return "neutral"
+}
+
+// Friendly is true for friends, party and guild.
+func (r Relationship) Friendly() bool {
+	return r == RelFriend || r == RelParty || r == RelGuild
+}
+
+// Next cycles neutral → friend → party → guild → enemy → neutral (edição
+// pelo overlay).
+func (r Relationship) Next() Relationship {
+	return (r + 1) % (RelEnemy + 1)
+}
+
+// Relater decides how an entity relates to the local player. NameLists is
+// the file-backed one; a faction offset from the profile can implement it
+// later and take precedence over the lists.
+type Relater interface {
+	Relation(e Entity) Relationship
+}
+
+// NameLists são as listas de nomes de relations.json. Um nome em mais de
+// uma lista fica com a última: friend, guild, party, enemy.
+type NameLists struct {
+	Friend []string `json:"friend"`
+	Guild  []string `json:"guild"`
+	Party  []string `json:"party"`
+	Enemy  []string `json:"enemy"`
+
+	mu    sync.RWMutex
+	file  string
+	names map[string]Relationship
+}
+
+// LoadNameLists reads filename, creating it with empty lists when missing.
+func LoadNameLists(filename string) (*NameLists, error) {
+	l := &NameLists{file: filename, Friend: []string{}, Guild: []string{}, Party: []string{}, Enemy: []string{}}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		l.index()
+		if err := l.Save(); err != nil {
+			return nil, err
+		}
+		fmt.Printf("[REL] Criado arquivo %s\n", filename)
+		return l, nil
+	}
+
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	l.index()
+
+	fmt.Printf("[REL] %d friend, %d guild, %d party, %d enemy\n", len(l.Friend), len(l.Guild), len(l.Party), len(l.Enemy))
+	return l, nil
+}
+
+func (l *NameLists) index() {
+	l.names = make(map[string]Relationship)
+	for _, list := range []struct {
+		names []string
+		rel   Relationship
+	}{{l.Friend, RelFriend}, {l.Guild, RelGuild}, {l.Party, RelParty}, {l.Enemy, RelEnemy}} {
+		for _, n := range list.names {
+			l.names[strings.ToLower(n)] = list.rel
+		}
+	}
+}
+
+func (l *NameLists) Relation(e Entity) Relationship {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.names[strings.ToLower(e.Name)]
+}
+
+// Set moves name to the list of rel (RelNeutral removes it from all lists)
+// and saves the file.
+func (l *NameLists) Set(name string, rel Relationship) error {
+	l.mu.Lock()
+	key := strings.ToLower(name)
+	remove := func(list []string) []string {
+		out := list[:0]
+		for _, n := range list {
+			if strings.ToLower(n) != key {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+	l.Friend, l.Guild, l.Party, l.Enemy = remove(l.Friend), remove(l.Guild), remove(l.Party), remove(l.Enemy)
+
+	switch rel {
+	case RelFriend:
+		l.Friend = append(l.Friend, name)
+	case RelGuild:
+		l.Guild = append(l.Guild, name)
+	case RelParty:
+		l.Party = append(l.Party, name)
+	case RelEnemy:
+		l.Enemy = append(l.Enemy, name)
+	}
+	l.index()
+	l.mu.Unlock()
+
+	return l.Save()
+}
+
+func (l *NameLists) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, list := range [][]string{l.Friend, l.Guild, l.Party, l.Enemy} {
+		sort.Slice(list, func(i, j int) bool {
+			return strings.ToLower(list[i]) < strings.ToLower(list[j])
+		})
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.file, data, 0644)
+}
+
+var relater atomic.Pointer[Relater]
+
+func SetRelater(r Relater) {
+	relater.Store(&r)
+}
+
+// Relate sets e.Relation from the active Relater.
+func Relate(e *Entity) {
+	e.Relation = RelNeutral
+	if r := relater.Load(); r != nil {
+		e.Relation = (*r).Relation(*e)
+	}
+}
@@ -0,0 +1,266 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+const (
+	scanChunk        = 0x10000  // leitura por ReadBytes
+	scanJobSize      = 0x100000 // unidade de trabalho distribuída aos workers
+	progressInterval = 100 * time.Millisecond
+)
+
+type ScanOptions struct {
+	MaxDistance float32
+	Workers     int // 0 = um por CPU
+
+	// Chamados na goroutine de Scan: OnEntity a cada entidade nova (em ordem
+	// de descoberta), OnProgress periodicamente e uma última vez no fim.
+	OnEntity   func(Entity)
+	OnProgress func(ScanProgress)
+}
+
+type ScanProgress struct {
+	Bytes      uint64
+	TotalBytes uint64
+	Jobs       int
+	TotalJobs  int
+	Found      int
+	Workers    int
+	Elapsed    time.Duration
+}
+
+func (p ScanProgress) Percent() float64 {
+	if p.TotalBytes == 0 {
+		return 100
+	}
+	return float64(p.Bytes) * 100 / float64(p.TotalBytes)
+}
+
+// scanJob is a slice of a heap region. Candidates start in [start, end);
+// reads may run up to limit (the region end) so the last ones are complete.
+type scanJob struct {
+	start, end, limit uintptr
+}
+
+func splitJobs(regions []memory.Region) ([]scanJob, uint64) {
+	var jobs []scanJob
+	var total uint64
+	for _, r := range regions {
+		// Entidades são ponteiros de 32 bits
+		if uint64(r.End()-1) > math.MaxUint32 {
+			continue
+		}
+		for start := r.Base; start < r.End(); start += scanJobSize {
+			end := start + scanJobSize
+			if end > r.End() {
+				end = r.End()
+			}
+			jobs = append(jobs, scanJob{start: start, end: end, limit: r.End()})
+			total += uint64(end - start)
+		}
+	}
+	return jobs, total
+}
+
+// Scan searches the heap for entities within opts.MaxDistance of player,
+// splitting the work across a worker pool. Cancelling ctx stops it early; the
+// entities found so far are returned along with ctx.Err().
+func Scan(ctx context.Context, mem memory.ProcessMemory, player Entity, opts ScanOptions) ([]Entity, ScanProgress, error) {
+	var prog ScanProgress
+
+	p := offsets.Active()
+	if p == nil {
+		return nil, prog, errors.New("nenhum perfil de offsets ativo")
+	}
+
+	filter := vtableFilter.Load()
+	if filter == nil {
+		return nil, prog, errors.New("filtro de vtable não configurado (x2game.dll desconhecido)")
+	}
+	vtables := filter.check()
+
+	// Bytes necessários a partir do início da entidade
+	span, err := memory.Span(Entity{}, p)
+	if err != nil {
+		return nil, prog, err
+	}
+
+	// Só heap de verdade: committed, legível e privado
+	amap, err := memory.LoadAddressMap(mem)
+	if err != nil {
+		return nil, prog, err
+	}
+	jobs, totalBytes := splitJobs(amap.Heap())
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var bytesDone atomic.Uint64
+	var jobsDone atomic.Int64
+	jobCh := make(chan scanJob)
+	found := make(chan Entity, 64)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buffer := make([]byte, scanChunk)
+			for job := range jobCh {
+				// Jobs interrompidos pelo cancelamento não contam no progresso
+				if !scanRange(ctx, mem, p, &vtables, span, player, opts.MaxDistance, job, buffer, found) {
+					continue
+				}
+				bytesDone.Add(uint64(job.end - job.start))
+				jobsDone.Add(1)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobCh)
+		for _, job := range jobs {
+			select {
+			case jobCh <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	var entities []Entity
+	seen := make(map[uint32]bool)
+	progress := func() ScanProgress {
+		return ScanProgress{
+			Bytes:      bytesDone.Load(),
+			TotalBytes: totalBytes,
+			Jobs:       int(jobsDone.Load()),
+			TotalJobs:  len(jobs),
+			Found:      len(entities),
+			Workers:    workers,
+			Elapsed:    time.Since(start),
+		}
+	}
+
+collect:
+	for {
+		select {
+		case e, ok := <-found:
+			if !ok {
+				break collect
+			}
+			// Chunks se sobrepõem, a mesma entidade pode vir duas vezes
+			if seen[e.Address] {
+				continue
+			}
+			seen[e.Address] = true
+			entities = append(entities, e)
+			if opts.OnEntity != nil {
+				opts.OnEntity(e)
+			}
+		case <-ticker.C:
+			if opts.OnProgress != nil {
+				opts.OnProgress(progress())
+			}
+		}
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Distance < entities[j].Distance
+	})
+
+	prog = progress()
+	if opts.OnProgress != nil {
+		opts.OnProgress(prog)
+	}
+	return entities, prog, ctx.Err()
+}
+
+// scanRange sends the entities of job to found; it reports false when ctx was
+// cancelled before the job was done.
+func scanRange(ctx context.Context, mem memory.ProcessMemory, p *offsets.Profile, vtables *vtableCheck, span uint32, player Entity, maxDistance float32, job scanJob, buffer []byte, found chan<- Entity) bool {
+	// Chunks se sobrepõem para não perder entidades na borda
+	step := uintptr(scanChunk - (span+3)&^3)
+
+	for addr := job.start; addr < job.end; addr += step {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		size := job.limit - addr
+		if size > scanChunk {
+			size = scanChunk
+		}
+		if size <= uintptr(span) {
+			return true
+		}
+
+		bytesRead, err := mem.ReadBytes(addr, buffer[:size])
+		if err != nil || bytesRead <= int(span) {
+			continue
+		}
+
+		last := uint32(bytesRead) - span
+		if inJob := uint32(job.end - addr); inJob < last {
+			last = inJob
+		}
+
+		for i := uint32(0); i < last; i += 4 {
+			vtable := *(*uint32)(unsafe.Pointer(&buffer[i]))
+			if !vtables.accept(vtable) {
+				continue
+			}
+
+			hp := *(*uint32)(unsafe.Pointer(&buffer[i+p.Entity.HP]))
+			if hp < 100 || hp > 10000000 {
+				continue
+			}
+
+			posX := *(*float32)(unsafe.Pointer(&buffer[i+p.Entity.PosX]))
+			posY := *(*float32)(unsafe.Pointer(&buffer[i+p.Entity.PosY]))
+			posZ := *(*float32)(unsafe.Pointer(&buffer[i+p.Entity.PosZ]))
+
+			if !memory.IsValidCoord(posX) || !memory.IsValidCoord(posY) || !memory.IsValidCoord(posZ) {
+				continue
+			}
+
+			distance := memory.CalculateDistance(player.PosX, player.PosY, player.PosZ, posX, posY, posZ)
+			if distance > maxDistance {
+				continue
+			}
+
+			candidateAddr := uint32(addr) + i
+			e := Entity{Address: candidateAddr, Distance: distance}
+			if memory.DecodeBytes(mem, buffer[i:bytesRead], uintptr(candidateAddr), &e, p) != nil {
+				continue
+			}
+			if !IsValidEntityName(e.Name) {
+				continue
+			}
+
+			found <- e
+		}
+	}
+	return true
+}
@@ -0,0 +1,273 @@
+package entity
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"muletinha/snapshot"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+var updateFixtures = flag.Bool("update", false, "regrava testdata/scan.snap")
+
+// Snapshot pequeno para o scanner: cabeçalho PE do x2game.dll, entidades
+// espalhadas pelo heap, uma falsa com "vtable" no .data e alguns MB de heap
+// sem nada.
+const (
+	scanFixture     = "testdata/scan.snap"
+	fixtureModule   = 0x00400000
+	fixtureImage    = 0x02000000 // SizeOfImage
+	fixtureVTable   = fixtureModule + 0x1100000
+	fixtureData     = fixtureModule + 0x1210000
+	fixtureEntities = 48
+	fixtureFiller   = 4 // regiões de 1 MB
+)
+
+// Seções do x2game.dll falso: as vtables ficam no .rdata
+var fixtureSections = []struct {
+	name       string
+	rva, size  uint32
+	executable bool
+}{
+	{".text", 0x1000, 0x1000000, true},
+	{".rdata", 0x1001000, 0x200000, false},
+	{".data", 0x1201000, 0x100000, false},
+}
+
+// peHeader builds the first page of a PE image with fixtureSections.
+func peHeader() []byte {
+	header := make([]byte, 0x1000)
+	copy(header, "MZ")
+	binary.LittleEndian.PutUint32(header[0x3C:], 0x80)
+	nt := header[0x80:]
+	copy(nt, "PE\x00\x00")
+	binary.LittleEndian.PutUint16(nt[6:], uint16(len(fixtureSections)))
+	binary.LittleEndian.PutUint32(nt[8:], 0x5F000000) // TimeDateStamp
+	binary.LittleEndian.PutUint16(nt[20:], 0xE0)      // SizeOfOptionalHeader
+	binary.LittleEndian.PutUint32(nt[24+56:], fixtureImage)
+
+	for i, sec := range fixtureSections {
+		h := nt[24+0xE0+i*40:]
+		copy(h, sec.name)
+		binary.LittleEndian.PutUint32(h[8:], sec.size)
+		binary.LittleEndian.PutUint32(h[12:], sec.rva)
+		if sec.executable {
+			binary.LittleEndian.PutUint32(h[36:], 0x60000020)
+		} else {
+			binary.LittleEndian.PutUint32(h[36:], 0x40000040)
+		}
+	}
+	return header
+}
+
+// addEntity builds an entity with the given vtable and returns its address.
+func (f *fakeMem) addEntity(p *offsets.Profile, vtable uint32, name string, x, y, z float32, hp, maxHP uint32) uintptr {
+	ent := f.alloc(0x1000)
+	f.putU32(ent, vtable)
+	f.putF32(ent+uintptr(p.Entity.PosX), x)
+	f.putF32(ent+uintptr(p.Entity.PosY), y)
+	f.putF32(ent+uintptr(p.Entity.PosZ), z)
+	f.putU32(ent+uintptr(p.Entity.HP), hp)
+
+	str := f.alloc(64)
+	f.put(str, []byte(name+"\x00"))
+	f.link(p.Chains.EntityName, ent, str)
+
+	// O último ponteiro da cadeia (alvo - Final) também tem que ser mapeado
+	stat := f.alloc(p.Chains.MaxHP.Final+4) + uintptr(p.Chains.MaxHP.Final)
+	f.putU32(stat, maxHP)
+	f.link(p.Chains.MaxHP, ent, stat)
+	return ent
+}
+
+func buildScanFixture(p *offsets.Profile) (*snapshot.File, error) {
+	f := newFakeMem()
+
+	f.img.Map(fixtureModule, peHeader())
+
+	for i := 0; i < fixtureEntities; i++ {
+		angle := float64(i) * 2 * math.Pi / fixtureEntities
+		r := float64(50 + 20*i)
+		f.addEntity(p, fixtureVTable, fmt.Sprintf("Entity%02d", i),
+			float32(1000+r*math.Cos(angle)), float32(2000+r*math.Sin(angle)), 100+float32(i),
+			uint32(1000+i*10), uint32(2000+i*10))
+	}
+	f.addEntity(p, fixtureData, "Fantasma", 1010, 2010, 100, 5000, 5000)
+	for i := 0; i < fixtureFiller; i++ {
+		f.img.Map(0x30000000+uintptr(i)*0x200000, make([]byte, 0x100000))
+	}
+
+	regions, err := f.img.Regions()
+	if err != nil {
+		return nil, err
+	}
+	file := &snapshot.File{
+		Version: snapshot.Version,
+		Process: "archeage.exe",
+		Modules: []snapshot.Module{{Name: "x2game.dll", Base: fixtureModule, Size: fixtureImage}},
+	}
+	for _, r := range regions {
+		data := make([]byte, r.Size)
+		if err := memory.ReadMemoryBytes(f.img, r.Base, data); err != nil {
+			return nil, err
+		}
+		file.Blocks = append(file.Blocks, snapshot.Block{Base: r.Base, Data: data})
+
+		// O módulo é imagem mapeada, não heap
+		if r.Base == fixtureModule {
+			r.Private = false
+			r.Size = fixtureImage
+		}
+		file.Regions = append(file.Regions, r)
+	}
+	return file, nil
+}
+
+// openScanFixture loads the snapshot and sets up the profile, address map and
+// vtable filter the way the bench command does.
+func openScanFixture(tb testing.TB) *snapshot.Snapshot {
+	tb.Helper()
+	p := offsets.Default()
+
+	if *updateFixtures {
+		file, err := buildScanFixture(p)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if err := file.Save(scanFixture); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	snap, err := snapshot.Open(scanFixture)
+	if err != nil {
+		tb.Fatalf("%v (gere com go test ./entity -run TestScanFixture -update)", err)
+	}
+
+	offsets.SetActive(p)
+	amap, err := memory.LoadAddressMap(snap)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	memory.SetAddressMap(amap)
+	filter, err := NewVTableFilter(snap, fixtureModule, p, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	SetVTableFilter(filter)
+
+	tb.Cleanup(func() {
+		offsets.SetActive(nil)
+		memory.SetAddressMap(nil)
+		SetVTableFilter(nil)
+	})
+	return snap
+}
+
+var fixturePlayer = Entity{PosX: 1000, PosY: 2000, PosZ: 100}
+
+func TestScanFixture(t *testing.T) {
+	snap := openScanFixture(t)
+
+	for _, workers := range []int{1, 4} {
+		entities, prog, err := Scan(context.Background(), snap, fixturePlayer, ScanOptions{
+			MaxDistance: math.MaxFloat32,
+			Workers:     workers,
+		})
+		if err != nil {
+			t.Fatalf("workers=%d: Scan: %v", workers, err)
+		}
+		if len(entities) != fixtureEntities || prog.Found != fixtureEntities {
+			t.Fatalf("workers=%d: found %d entities (progress %d), want %d", workers, len(entities), prog.Found, fixtureEntities)
+		}
+		if prog.Bytes != prog.TotalBytes || prog.Jobs != prog.TotalJobs {
+			t.Errorf("workers=%d: scan incomplete: %+v", workers, prog)
+		}
+
+		// Ordenadas por distância: a primeira é a mais próxima
+		if entities[0].Name != "Entity00" || entities[0].MaxHP != 2000 {
+			t.Errorf("workers=%d: nearest = %+v", workers, entities[0])
+		}
+		for i := 1; i < len(entities); i++ {
+			if entities[i].Distance < entities[i-1].Distance {
+				t.Fatalf("workers=%d: entities not sorted by distance at %d", workers, i)
+			}
+		}
+	}
+
+	// Com limite de distância só entram as próximas (raio 50+20i)
+	near, _, err := Scan(context.Background(), snap, fixturePlayer, ScanOptions{MaxDistance: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(near) == 0 || len(near) >= fixtureEntities {
+		t.Errorf("MaxDistance 200 found %d entities", len(near))
+	}
+}
+
+// cancelOnRead cancels the scan on its first read, like the game detaching
+// right after the scan started.
+type cancelOnRead struct {
+	*snapshot.Snapshot
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (c *cancelOnRead) ReadBytes(addr uintptr, buf []byte) (int, error) {
+	c.once.Do(c.cancel)
+	return c.Snapshot.ReadBytes(addr, buf)
+}
+
+func TestScanCancel(t *testing.T) {
+	snap := openScanFixture(t)
+	opts := ScanOptions{MaxDistance: math.MaxFloat32, Workers: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	entities, prog, err := Scan(ctx, snap, fixturePlayer, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled before start: err = %v", err)
+	}
+	if len(entities) != 0 || prog.Jobs != 0 || prog.Bytes != 0 {
+		t.Errorf("cancelled before start: %d entities, %+v", len(entities), prog)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, prog, err = Scan(ctx, &cancelOnRead{Snapshot: snap, cancel: cancel}, fixturePlayer, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled mid-scan: err = %v", err)
+	}
+	if prog.Jobs >= prog.TotalJobs || prog.Bytes >= prog.TotalBytes {
+		t.Errorf("cancelled mid-scan ran to the end: %+v", prog)
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	snap := openScanFixture(b)
+
+	// Com uma CPU só ainda compara com 4 workers
+	n := runtime.NumCPU()
+	if n < 2 {
+		n = 4
+	}
+	for _, workers := range []int{1, n} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := ScanOptions{MaxDistance: math.MaxFloat32, Workers: workers}
+			for i := 0; i < b.N; i++ {
+				_, prog, err := Scan(context.Background(), snap, fixturePlayer, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.SetBytes(int64(prog.TotalBytes))
+			}
+		})
+	}
+}
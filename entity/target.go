@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"muletinha/memory"
+	"muletinha/offsets"
+)
+
+// Target é a estrutura de UI do alvo atual, lida pela cadeia "target" do
+// perfil. ID, tipo e level ainda são palpites (config.OFF_TARGET_*), por
+// isso opcionais.
+type Target struct {
+	Address uint32
+	ID      uint32 `mem:"target.id,opt"`
+	Type    uint32 `mem:"target.type,opt"`
+	Level   uint32 `mem:"target.level,opt"`
+	HP      uint32 `mem:"target.hp"`
+	MaxHP   uint32 `mem:"target.max_hp"`
+	Mana    uint32 `mem:"target.mana"`
+	MaxMana uint32 `mem:"target.max_mana"`
+}
+
+// TargetSupported reports whether the active profile knows where the target
+// structure is (a chain base or a resolved signature).
+func TargetSupported() bool {
+	p := offsets.Active()
+	return p != nil && p.Chains.Target.Base != 0
+}
+
+// GetTarget reads the current target. ok is false when nothing is targeted
+// or the profile has no target chain.
+func GetTarget(mem memory.ProcessMemory, x2game uintptr) (t Target, ok bool) {
+	p := offsets.Active()
+	if p == nil || p.Chains.Target.Base == 0 {
+		return t, false
+	}
+
+	addr, err := p.Chains.Target.Resolve(mem, x2game)
+	if err != nil {
+		return t, false
+	}
+	if err := memory.Decode(mem, addr, &t, p); err != nil {
+		return Target{}, false
+	}
+	t.Address = uint32(addr)
+
+	// Sem alvo a estrutura fica zerada ou com lixo
+	if t.MaxHP == 0 || t.HP > t.MaxHP || t.Mana > t.MaxMana {
+		return Target{}, false
+	}
+	return t, true
+}
+
+// Match finds the tracked entity the target refers to: by ID when both sides
+// have one, otherwise by max HP and HP when exactly one entity fits.
+func (t Target) Match(entities []Tracked) (Tracked, bool) {
+	if t.ID != 0 {
+		for _, e := range entities {
+			if e.ID == t.ID {
+				return e, true
+			}
+		}
+	}
+
+	var found Tracked
+	n := 0
+	for _, e := range entities {
+		if e.MaxHP == t.MaxHP && e.HP == t.HP {
+			found = e
+			n++
+		}
+	}
+	return found, n == 1
+}
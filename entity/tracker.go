@@ -0,0 +1,335 @@
+package entity
+
+import (
+	"math"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"sort"
+	"sync"
+	"time"
+)
+
+type EventType int
+
+const (
+	Appeared EventType = iota
+	Left
+	Died
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Appeared:
+		return "Appeared"
+	case Left:
+		return "Left"
+	case Died:
+		return "Died"
+	}
+	return "?"
+}
+
+type Event struct {
+	Type   EventType
+	Entity Entity
+	Time   time.Time
+}
+
+// Tracked is an entity followed across scans, with motion estimated from
+// consecutive refreshes.
+type Tracked struct {
+	Entity
+
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	VelX, VelY, VelZ float32 // m/s
+	Speed            float32 // m/s no plano
+	Heading          float32 // radianos, 0 = norte (+Y), sentido horário
+	Dead             bool
+
+	// Últimas posições, um ponto a cada trailInterval enquanto se move. Array
+	// fixo para Entities() copiar sem compartilhar memória com o tracker.
+	trail      [TrailLen]TrailPoint
+	trailCount int
+	trailHead  int // próxima posição a escrever
+}
+
+type TrailPoint struct {
+	X, Y, Z float32
+	Time    time.Time
+}
+
+// TrailLen pontos a cada trailInterval: ~20s de rastro
+const (
+	TrailLen      = 40
+	trailInterval = 500 * time.Millisecond
+	trailMinStep  = 0.5 // metros; parado não gasta pontos
+)
+
+// Trail returns the recorded positions, oldest first.
+func (t *Tracked) Trail() []TrailPoint {
+	out := make([]TrailPoint, 0, t.trailCount)
+	start := t.trailHead - t.trailCount
+	if start < 0 {
+		start += TrailLen
+	}
+	for i := 0; i < t.trailCount; i++ {
+		out = append(out, t.trail[(start+i)%TrailLen])
+	}
+	return out
+}
+
+func (t *Tracked) addTrail(x, y, z float32, now time.Time) {
+	if t.trailCount > 0 {
+		last := t.trail[(t.trailHead+TrailLen-1)%TrailLen]
+		if now.Sub(last.Time) < trailInterval {
+			return
+		}
+		dx, dy, dz := x-last.X, y-last.Y, z-last.Z
+		if dx*dx+dy*dy+dz*dz < trailMinStep*trailMinStep {
+			return
+		}
+	}
+	t.trail[t.trailHead] = TrailPoint{X: x, Y: y, Z: z, Time: now}
+	t.trailHead = (t.trailHead + 1) % TrailLen
+	if t.trailCount < TrailLen {
+		t.trailCount++
+	}
+}
+
+// Peso da amostra nova na média móvel da velocidade
+const velocitySmoothing = 0.5
+
+// Abaixo disso o heading não é atualizado (parado ou ruído)
+const minHeadingSpeed = 0.5
+
+// entityState is what Refresh reads for a known entity: one bulk read, no
+// chains (name and max HP don't change between scans).
+type entityState struct {
+	VTable uint32  `mem:"0x0"`
+	ID     uint32  `mem:"entity.id,opt"`
+	PosX   float32 `mem:"entity.pos_x"`
+	PosY   float32 `mem:"entity.pos_y"`
+	PosZ   float32 `mem:"entity.pos_z"`
+	HP     uint32  `mem:"entity.hp"`
+	Facing float32 `mem:"entity.heading,opt"`
+}
+
+// Tracker keeps entities keyed by address between full scans. Full scans add
+// and drop entities; Refresh updates the known ones every few frames.
+type Tracker struct {
+	mu       sync.RWMutex
+	entities map[uint32]*Tracked
+	events   []Event
+	gen      uint64 // incrementado por Clear
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{entities: make(map[uint32]*Tracked)}
+}
+
+func (t *Tracker) emit(typ EventType, e Entity, now time.Time) {
+	t.events = append(t.events, Event{Type: typ, Entity: e, Time: now})
+}
+
+// Generation identifies the tracker contents between two Clear calls. A scan
+// records it when it starts and passes it back with its results.
+func (t *Tracker) Generation() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.gen
+}
+
+// Observe adds or updates an entity found by the scan started at gen. Results
+// of a scan started before the last Clear are dropped.
+func (t *Tracker) Observe(gen uint64, e Entity, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if gen != t.gen {
+		return
+	}
+	t.observe(e, now)
+}
+
+func (t *Tracker) observe(e Entity, now time.Time) {
+	tr, ok := t.entities[e.Address]
+
+	// Mesmo endereço com outro ID: a memória foi reaproveitada
+	if ok && e.ID != 0 && tr.ID != 0 && e.ID != tr.ID {
+		delete(t.entities, e.Address)
+		t.emit(Left, tr.Entity, now)
+		ok = false
+	}
+
+	// Mesmo ID em outro endereço: a entidade foi realocada, mantém a identidade
+	if !ok && e.ID != 0 {
+		for addr, old := range t.entities {
+			if old.ID == e.ID {
+				delete(t.entities, addr)
+				tr, ok = old, true
+				break
+			}
+		}
+	}
+
+	if !ok {
+		tr = &Tracked{Entity: e, FirstSeen: now, LastSeen: now}
+		tr.addTrail(e.PosX, e.PosY, e.PosZ, now)
+		t.entities[e.Address] = tr
+		t.emit(Appeared, e, now)
+		return
+	}
+
+	t.entities[e.Address] = tr
+	t.move(tr, e.PosX, e.PosY, e.PosZ, now)
+	tr.Entity = e
+	tr.LastSeen = now
+}
+
+// EndScan drops every entity a completed full scan, started at gen, did not
+// find. It does nothing if the tracker was cleared since.
+func (t *Tracker) EndScan(gen uint64, found []Entity, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if gen != t.gen {
+		return
+	}
+
+	present := make(map[uint32]bool, len(found))
+	for _, e := range found {
+		t.observe(e, now)
+		present[e.Address] = true
+	}
+	for addr, tr := range t.entities {
+		if !present[addr] {
+			delete(t.entities, addr)
+			t.emit(Left, tr.Entity, now)
+		}
+	}
+}
+
+// Refresh re-reads position and HP of every tracked entity (one read each)
+// and recomputes distances from player. Entities whose memory no longer
+// looks like the same object are dropped.
+func (t *Tracker) Refresh(mem memory.ProcessMemory, player Entity, now time.Time) {
+	p := offsets.Active()
+	if p == nil {
+		return
+	}
+
+	t.mu.RLock()
+	addrs := make([]uint32, 0, len(t.entities))
+	for addr := range t.entities {
+		addrs = append(addrs, addr)
+	}
+	t.mu.RUnlock()
+
+	states := make(map[uint32]*entityState, len(addrs))
+	for _, addr := range addrs {
+		var s entityState
+		if memory.Decode(mem, uintptr(addr), &s, p) == nil {
+			states[addr] = &s
+		} else {
+			states[addr] = nil
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for addr, s := range states {
+		tr, ok := t.entities[addr]
+		if !ok {
+			continue
+		}
+
+		if s == nil || s.VTable != tr.VTable || (tr.ID != 0 && s.ID != tr.ID) ||
+			!memory.IsValidCoord(s.PosX) || !memory.IsValidCoord(s.PosY) || !memory.IsValidCoord(s.PosZ) {
+			delete(t.entities, addr)
+			t.emit(Left, tr.Entity, now)
+			continue
+		}
+
+		t.move(tr, s.PosX, s.PosY, s.PosZ, now)
+		tr.PosX, tr.PosY, tr.PosZ = s.PosX, s.PosY, s.PosZ
+		tr.HP = s.HP
+		tr.Facing = s.Facing
+		tr.Distance = memory.CalculateDistance(player.PosX, player.PosY, player.PosZ, s.PosX, s.PosY, s.PosZ)
+		tr.LastSeen = now
+
+		if s.HP == 0 && !tr.Dead {
+			tr.Dead = true
+			t.emit(Died, tr.Entity, now)
+		} else if s.HP > 0 {
+			tr.Dead = false
+		}
+	}
+}
+
+// move updates velocity, heading and the trail from the previous position.
+func (t *Tracker) move(tr *Tracked, x, y, z float32, now time.Time) {
+	dt := float32(now.Sub(tr.LastSeen).Seconds())
+	if dt <= 0 {
+		return
+	}
+	tr.addTrail(x, y, z, now)
+
+	vx := (x - tr.PosX) / dt
+	vy := (y - tr.PosY) / dt
+	vz := (z - tr.PosZ) / dt
+	tr.VelX += (vx - tr.VelX) * velocitySmoothing
+	tr.VelY += (vy - tr.VelY) * velocitySmoothing
+	tr.VelZ += (vz - tr.VelZ) * velocitySmoothing
+
+	tr.Speed = float32(math.Hypot(float64(tr.VelX), float64(tr.VelY)))
+	if tr.Speed >= minHeadingSpeed {
+		tr.Heading = float32(math.Atan2(float64(tr.VelX), float64(tr.VelY)))
+	}
+}
+
+// Entities returns a copy of the tracked entities sorted by distance.
+func (t *Tracker) Entities() []Tracked {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]Tracked, 0, len(t.entities))
+	for _, tr := range t.entities {
+		list = append(list, *tr)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Distance < list[j].Distance
+	})
+	return list
+}
+
+// Events returns and clears the pending lifecycle events.
+func (t *Tracker) Events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := t.events
+	t.events = nil
+	return events
+}
+
+// Relabel re-applies the classifier and relations to every tracked entity,
+// after the rules or lists change.
+func (t *Tracker) Relabel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tr := range t.entities {
+		Classify(&tr.Entity)
+		Relate(&tr.Entity)
+	}
+}
+
+// Clear drops everything without emitting events, e.g. after a teleport, and
+// starts a new generation so a scan still running can't add entities back.
+func (t *Tracker) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entities = make(map[uint32]*Tracked)
+	t.events = nil
+	t.gen++
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerClearDropsStaleScan(t *testing.T) {
+	tr := NewTracker()
+	now := time.Now()
+	old := Entity{Address: 0x20000000, Name: "Antigo"}
+
+	gen := tr.Generation()
+	tr.Observe(gen, old, now)
+	tr.Events()
+
+	// Teleporte no meio do scan: o que ele ainda achar é da posição antiga
+	tr.Clear()
+	tr.Observe(gen, Entity{Address: 0x20010000, Name: "Atrasado"}, now)
+	tr.EndScan(gen, []Entity{old}, now)
+	if n := len(tr.Entities()); n != 0 {
+		t.Fatalf("stale scan left %d entities", n)
+	}
+	if ev := tr.Events(); len(ev) != 0 {
+		t.Fatalf("stale scan emitted %v", ev)
+	}
+
+	// O scan seguinte usa a geração nova
+	fresh := Entity{Address: 0x20020000, Name: "Novo"}
+	gen = tr.Generation()
+	tr.Observe(gen, fresh, now)
+	tr.EndScan(gen, []Entity{fresh}, now)
+	ev := tr.Events()
+	if len(tr.Entities()) != 1 || len(ev) != 1 || ev[0].Type != Appeared || ev[0].Entity.Name != "Novo" {
+		t.Fatalf("fresh scan: entities %v, events %v", tr.Entities(), ev)
+	}
+}
@@ -0,0 +1,244 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"muletinha/sigscan"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Entidades são objetos C++ do x2game.dll: o primeiro dword é a vtable, que
+// fica na seção .rdata do módulo. O filtro trabalha com RVAs, então continua
+// válido quando o DLL carrega em outro endereço (ASLR).
+
+// VTableFilter accepts candidate vtables that point into the .rdata section of
+// x2game.dll (or the RVA range set by the profile) and, once the build has
+// enough confirmed vtables, only those.
+type VTableFilter struct {
+	Base   uintptr
+	MinRVA uint32
+	MaxRVA uint32 // exclusivo
+	Known  *VTableSet
+}
+
+// NewVTableFilter builds the filter for the module mapped at base, using the
+// profile's entity.vtable_min/max or else the .rdata section from the PE
+// section table (the whole SizeOfImage when there is none).
+func NewVTableFilter(pm memory.ProcessMemory, base uintptr, p *offsets.Profile, known *VTableSet) (*VTableFilter, error) {
+	f := &VTableFilter{Base: base, Known: known}
+	if p.Entity.VTableMin != 0 || p.Entity.VTableMax != 0 {
+		f.MinRVA, f.MaxRVA = p.Entity.VTableMin, p.Entity.VTableMax
+		return f, nil
+	}
+
+	headers, err := sigscan.ReadSectionHeaders(pm, base)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range headers {
+		if h.Name == ".rdata" && h.Size > 0 {
+			f.MinRVA, f.MaxRVA = h.RVA, h.RVA+h.Size
+			return f, nil
+		}
+	}
+
+	build, err := offsets.ReadBuildID(pm, base)
+	if err != nil {
+		return nil, err
+	}
+	if build.SizeOfImage == 0 {
+		return nil, fmt.Errorf("SizeOfImage zerado em %08X", base)
+	}
+	fmt.Println("[VTABLE] x2game.dll sem seção .rdata, aceitando a imagem inteira")
+	f.MaxRVA = build.SizeOfImage
+	return f, nil
+}
+
+// RVA converts an absolute vtable address; ok is false outside the module.
+func (f *VTableFilter) RVA(vtable uint32) (uint32, bool) {
+	if uintptr(vtable) < f.Base {
+		return 0, false
+	}
+	rva := uintptr(vtable) - f.Base
+	return uint32(rva), rva >= uintptr(f.MinRVA) && rva < uintptr(f.MaxRVA)
+}
+
+// vtableCheck is an immutable copy of the filter for one scan, so the
+// workers don't lock anything in the hot loop.
+type vtableCheck struct {
+	lo, hi uint32
+	known  map[uint32]bool // nil = aceita qualquer vtable na faixa
+}
+
+func (f *VTableFilter) check() vtableCheck {
+	c := vtableCheck{lo: uint32(f.Base) + f.MinRVA, hi: uint32(f.Base) + f.MaxRVA}
+	if f.Known != nil && f.Known.Enforced() {
+		c.known = f.Known.snapshot(uint32(f.Base))
+	}
+	return c
+}
+
+func (c *vtableCheck) accept(vtable uint32) bool {
+	if vtable < c.lo || vtable >= c.hi {
+		return false
+	}
+	return c.known == nil || c.known[vtable]
+}
+
+// Com pelo menos isso de vtables confirmadas o scanner só aceita elas
+const MinEnforcedVTables = 8
+
+// VTableSet is the set of vtable RVAs confirmed as entities for one build,
+// kept in profiles/vtables/<build>.json.
+type VTableSet struct {
+	mu     sync.RWMutex
+	file   string
+	strict *bool // nil = automático (MinEnforcedVTables)
+	rvas   map[uint32]bool
+	dirty  bool
+}
+
+type vtableFile struct {
+	// true: só vtables conhecidas desde a primeira; false: nunca restringe;
+	// ausente: restringe a partir de MinEnforcedVTables
+	Strict  *bool    `json:"strict,omitempty"`
+	VTables []string `json:"vtables"`
+}
+
+// VTableFile is where the learned vtables of build are stored, named by the
+// PE timestamp so the live client and its snapshots share the file.
+func VTableFile(build offsets.BuildID) string {
+	name := build.Short()
+	if build.TimeDateStamp != 0 {
+		name = fmt.Sprintf("%08X", build.TimeDateStamp)
+	}
+	return filepath.Join(offsets.ProfileDir, "vtables", name+".json")
+}
+
+// LoadVTableSet reads filename; a missing file is an empty, non-strict set.
+func LoadVTableSet(filename string) (*VTableSet, error) {
+	s := &VTableSet{file: filename, rvas: make(map[uint32]bool)}
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f vtableFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	for _, v := range f.VTables {
+		rva, err := memory.ParseHex(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		s.rvas[rva] = true
+	}
+	s.strict = f.Strict
+	if s.strict != nil && *s.strict && len(s.rvas) == 0 {
+		fmt.Printf("[VTABLE] %s: strict sem vtables conhecidas, ignorado\n", filename)
+		s.strict = nil
+	}
+	return s, nil
+}
+
+// Enforced reports whether the scanner accepts only the known vtables: always
+// with "strict": true, never with false, and otherwise once the set has
+// MinEnforcedVTables entries.
+func (s *VTableSet) Enforced() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.strict != nil {
+		return *s.strict && len(s.rvas) > 0
+	}
+	return len(s.rvas) >= MinEnforcedVTables
+}
+
+func (s *VTableSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rvas)
+}
+
+// Add records rva; it reports whether it was new.
+func (s *VTableSet) Add(rva uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rvas[rva] {
+		return false
+	}
+	s.rvas[rva] = true
+	s.dirty = true
+	return true
+}
+
+func (s *VTableSet) snapshot(base uint32) map[uint32]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	abs := make(map[uint32]bool, len(s.rvas))
+	for rva := range s.rvas {
+		abs[base+rva] = true
+	}
+	return abs
+}
+
+// Save writes the set if it changed since the last load or save.
+func (s *VTableSet) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+
+	f := vtableFile{Strict: s.strict}
+	rvas := make([]uint32, 0, len(s.rvas))
+	for rva := range s.rvas {
+		rvas = append(rvas, rva)
+	}
+	sort.Slice(rvas, func(i, j int) bool { return rvas[i] < rvas[j] })
+	for _, rva := range rvas {
+		f.VTables = append(f.VTables, fmt.Sprintf("0x%X", rva))
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(s.file), 0755)
+	if err := os.WriteFile(s.file, data, 0644); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+var vtableFilter atomic.Pointer[VTableFilter]
+
+// SetVTableFilter sets the filter used by Scan and by classifier rules.
+func SetVTableFilter(f *VTableFilter) {
+	vtableFilter.Store(f)
+}
+
+func ActiveVTableFilter() *VTableFilter {
+	return vtableFilter.Load()
+}
+
+// Learn records the vtable of e as a confirmed entity vtable.
+func Learn(e Entity) bool {
+	f := vtableFilter.Load()
+	if f == nil || f.Known == nil {
+		return false
+	}
+	rva, ok := f.RVA(e.VTable)
+	return ok && f.Known.Add(rva)
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"fmt"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewVTableFilter(t *testing.T) {
+	img := memory.NewImage()
+	img.Map(fixtureModule, peHeader())
+
+	custom := offsets.Default()
+	custom.Entity.VTableMin, custom.Entity.VTableMax = 0x1100000, 0x1100100
+
+	tests := []struct {
+		name     string
+		profile  *offsets.Profile
+		min, max uint32
+	}{
+		{name: ".rdata by default", profile: offsets.Default(), min: 0x1001000, max: 0x1201000},
+		{name: "profile range", profile: custom, min: 0x1100000, max: 0x1100100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewVTableFilter(img, fixtureModule, tt.profile, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.MinRVA != tt.min || f.MaxRVA != tt.max {
+				t.Errorf("range = 0x%X-0x%X, want 0x%X-0x%X", f.MinRVA, f.MaxRVA, tt.min, tt.max)
+			}
+		})
+	}
+
+	f, _ := NewVTableFilter(img, fixtureModule, offsets.Default(), nil)
+	c := f.check()
+	for _, tc := range []struct {
+		vtable uint32
+		want   bool
+	}{
+		{fixtureModule + 0x80, false},   // headers
+		{fixtureModule + 0x2000, false}, // .text
+		{fixtureVTable, true},
+		{fixtureData, false},
+	} {
+		if got := c.accept(tc.vtable); got != tc.want {
+			t.Errorf("accept(%08X) = %v, want %v", tc.vtable, got, tc.want)
+		}
+	}
+}
+
+// vtableSetFile writes a vtables file with n entries and the given strict
+// line ("" = absent).
+func vtableSetFile(t *testing.T, n int, strict string) string {
+	t.Helper()
+	data := "{"
+	if strict != "" {
+		data += `"strict": ` + strict + ", "
+	}
+	data += `"vtables": [`
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			data += ", "
+		}
+		data += fmt.Sprintf(`"0x%X"`, 0x1100000+i*0x100)
+	}
+	data += "]}"
+
+	filename := filepath.Join(t.TempDir(), "vtables.json")
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestVTableSetEnforced(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		strict string
+		want   bool
+	}{
+		{name: "few, automatic", n: MinEnforcedVTables - 1, want: false},
+		{name: "enough, automatic", n: MinEnforcedVTables, want: true},
+		{name: "strict from the first", n: 1, strict: "true", want: true},
+		{name: "strict without entries", n: 0, strict: "true", want: false},
+		{name: "never strict", n: MinEnforcedVTables * 2, strict: "false", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := LoadVTableSet(vtableSetFile(t, tt.n, tt.strict))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Enforced(); got != tt.want {
+				t.Errorf("Enforced() = %v, want %v", got, tt.want)
+			}
+
+			// Enforced: uma vtable do .rdata que não está no conjunto é recusada
+			f := &VTableFilter{Base: fixtureModule, MinRVA: 0x1001000, MaxRVA: 0x1201000, Known: s}
+			c := f.check()
+			if got := c.accept(fixtureModule + 0x1180000); got == tt.want {
+				t.Errorf("accept(unknown .rdata vtable) = %v with Enforced() = %v", got, tt.want)
+			}
+		})
+	}
+
+	// Aprender a vtable que completa MinEnforcedVTables liga a restrição
+	s, _ := LoadVTableSet(vtableSetFile(t, MinEnforcedVTables-1, ""))
+	s.Add(0x1180000)
+	if !s.Enforced() {
+		t.Error("Enforced() = false after reaching MinEnforcedVTables")
+	}
+}
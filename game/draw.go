@@ -3,10 +3,15 @@ package game
 import (
     "fmt"
     "image/color"
+    "math"
     "muletinha/config"
     "muletinha/entity"
+    "muletinha/monitor"
+    "muletinha/offsets"
     "muletinha/ui"
+    "sort"
     "strings"
+    "time"
 
     "github.com/hajimehoshi/ebiten/v2"
     "github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -27,6 +32,11 @@ var (
     colorPurple     = color.RGBA{150, 100, 255, 255}
     colorCyan       = color.RGBA{50, 200, 200, 255}
     colorBlue       = color.RGBA{50, 120, 220, 255}
+
+    colorEnemy  = color.RGBA{255, 20, 60, 255}
+    colorFriend = color.RGBA{140, 255, 140, 255}
+    colorParty  = color.RGBA{80, 170, 255, 255}
+    colorGuild  = color.RGBA{60, 210, 160, 255}
 )
 
 func (g *Game) Draw(screen *ebiten.Image) {
@@ -37,11 +47,21 @@ func (g *Game) Draw(screen *ebiten.Image) {
         return
     }
 
+    if g.profile == nil {
+        cx, cy := config.SCREEN_WIDTH/2, config.SCREEN_HEIGHT/2
+        g.drawCenteredText(screen, "BUILD NAO SUPORTADA - overlay desativado", cx, cy-30)
+        g.drawCenteredText(screen, "x2game.dll "+g.build.String(), cx, cy)
+        g.drawCenteredText(screen, "Adicione um perfil de offsets em "+offsets.ProfileDir+"/ para esta build", cx, cy+20)
+        if g.retryProfile {
+            g.drawCenteredText(screen, "Testando os offsets padrão a cada 5s (entre com o personagem)", cx, cy+40)
+        }
+        return
+    }
+
     g.mutex.RLock()
     localPlayer := g.localPlayer
-    entities := make([]entity.Entity, len(g.entities))
-    copy(entities, g.entities)
     g.mutex.RUnlock()
+    entities := g.tracker.Entities()
 
     if localPlayer.Address == 0 {
         g.drawCenteredText(screen, "Aguardando LocalPlayer...", config.SCREEN_WIDTH/2, config.SCREEN_HEIGHT/2)
@@ -56,7 +76,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
     bottomPanelH := float32(180)
 
     // === LEFT PANEL ===
-    g.drawLeftPanel(screen, localPlayer, leftPanelX, 10, leftPanelW)
+    g.drawLeftPanel(screen, localPlayer, entities, leftPanelX, 10, leftPanelW)
 
     // === CENTER - RADAR ===
     radarY := float32(280)
@@ -72,7 +92,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
     ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %.0f", ebiten.ActualFPS()), config.SCREEN_WIDTH-80, 10)
 }
 
-func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, x, y, w float32) {
+func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, entities []entity.Tracked, x, y, w float32) {
     panelH := float32(720)
 
     // Background
@@ -88,7 +108,7 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, x, y, w
     g.drawSectionHeader(screen, "PLAYER INFO", innerX, currentY, innerW)
     currentY += 25
 
-    ebitenutil.DebugPrintAt(screen, player.Name, int(innerX), int(currentY))
+    ui.DrawText(screen, player.Name, int(innerX), int(currentY))
     currentY += 16
     ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Pos: %.0f, %.0f, %.0f", player.PosX, player.PosY, player.PosZ), int(innerX), int(currentY))
     currentY += 25
@@ -159,6 +179,12 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, x, y, w
     ebitenutil.DebugPrintAt(screen, mpText, int(innerX)+int(innerW/2)-mpTextW/2, int(currentY)+5)
     currentY += manaBarH + 20
 
+    // === TARGET ===
+    currentY = g.drawTarget(screen, entities, innerX, currentY, innerW)
+
+    // === MOUNT ===
+    currentY = g.drawMount(screen, innerX, currentY, innerW)
+
     // === STATUS ===
     g.drawSectionHeader(screen, "STATUS", innerX, currentY, innerW)
     currentY += 25
@@ -166,23 +192,23 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, x, y, w
     // CC Break status
     ccColor := colorRed
     ccStatus := "OFF"
-    if g.debuffMonitor.CCWhitelist.Enabled {
+    if g.debuffMonitor.BreakEnabled {
         ccColor = colorGreen
         ccStatus = "ON"
     }
     vector.DrawFilledRect(screen, innerX, currentY, 12, 12, ccColor, false)
-    ebitenutil.DebugPrintAt(screen, fmt.Sprintf(" CC Break: %s (%d)", ccStatus, g.debuffMonitor.CCWhitelist.Reactions), int(innerX)+14, int(currentY)-1)
+    ebitenutil.DebugPrintAt(screen, fmt.Sprintf(" CC Break: %s (%d)", ccStatus, g.debuffMonitor.Reactions), int(innerX)+14, int(currentY)-1)
     currentY += 18
 
     // Buff Break status
     buffColor := colorRed
     buffStatus := "OFF"
-    if g.buffMonitor.Whitelist.Enabled {
+    if g.buffMonitor.BreakEnabled {
         buffColor = colorGreen
         buffStatus = "ON"
     }
     vector.DrawFilledRect(screen, innerX, currentY, 12, 12, buffColor, false)
-    ebitenutil.DebugPrintAt(screen, fmt.Sprintf(" Buff Break: %s (%d)", buffStatus, g.buffMonitor.Whitelist.Reactions), int(innerX)+14, int(currentY)-1)
+    ebitenutil.DebugPrintAt(screen, fmt.Sprintf(" Buff Break: %s (%d)", buffStatus, g.buffMonitor.Reactions), int(innerX)+14, int(currentY)-1)
     currentY += 18
 
     freezeColor := colorRed
@@ -282,62 +308,291 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, player entity.Entity, x, y, w
     }
 }
 
-func (g *Game) drawRadar(screen *ebiten.Image, player entity.Entity, entities []entity.Entity, centerX, centerY float32) {
+// drawTarget mostra o alvo atual e, quando encontrado na lista do tracker, o
+// nome e a distância dele. Retorna o Y seguinte.
+func (g *Game) drawTarget(screen *ebiten.Image, entities []entity.Tracked, x, y, w float32) float32 {
+    g.drawSectionHeader(screen, "TARGET", x, y, w)
+    y += 25
+
+    g.mutex.RLock()
+    t, ok := g.target, g.hasTarget
+    g.mutex.RUnlock()
+
+    if !entity.TargetSupported() {
+        ebitenutil.DebugPrintAt(screen, "(sem cadeia de target no perfil)", int(x), int(y))
+        return y + 16 + 15
+    }
+    if !ok {
+        ebitenutil.DebugPrintAt(screen, "(none)", int(x), int(y))
+        return y + 16 + 15
+    }
+
+    label := fmt.Sprintf("ID:%d", t.ID)
+    if e, found := t.Match(entities); found {
+        _, typeChar := kindStyle(e.Kind)
+        label = fmt.Sprintf("[%s] %s  %.0fm", typeChar, ui.TruncStr(e.Name, 20), e.Distance)
+    }
+    if t.Level != 0 {
+        label += fmt.Sprintf("  Lv %d", t.Level)
+    }
+    ui.DrawText(screen, label, int(x), int(y))
+    y += 16
+
+    hpPercent := float32(t.HP) / float32(t.MaxHP)
+    barH := float32(14)
+    vector.DrawFilledRect(screen, x, y, w, barH, color.RGBA{30, 30, 30, 255}, false)
+    vector.DrawFilledRect(screen, x, y, w*hpPercent, barH, colorRed, false)
+    vector.StrokeRect(screen, x, y, w, barH, 1, colorBorder, false)
+    hpText := fmt.Sprintf("%d / %d  (%.0f%%)", t.HP, t.MaxHP, hpPercent*100)
+    ebitenutil.DebugPrintAt(screen, hpText, int(x)+int(w/2)-len(hpText)*7/2, int(y)-1)
+    y += barH + 4
+
+    if t.MaxMana > 0 {
+        ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Mana: %d / %d", t.Mana, t.MaxMana), int(x), int(y))
+    }
+    return y + 16 + 15
+}
+
+// drawMount mostra a montaria atual com a barra de HP e a linha do
+// SkillKey. Retorna o Y seguinte.
+func (g *Game) drawMount(screen *ebiten.Image, x, y, w float32) float32 {
+    g.drawSectionHeader(screen, "MOUNT", x, y, w)
+    y += 25
+
+    g.mutex.RLock()
+    m := g.playerMount
+    g.mutex.RUnlock()
+
+    if m.Address == 0 {
+        ebitenutil.DebugPrintAt(screen, "(none)", int(x), int(y))
+        return y + 16 + 15
+    }
+
+    ui.DrawText(screen, ui.TruncStr(m.Name, 18), int(x), int(y))
+
+    hpPercent := float32(0)
+    if m.MaxHP > 0 {
+        hpPercent = float32(m.HP) / float32(m.MaxHP)
+    }
+    barX := x + 150
+    barW := w - 150
+    barH := float32(14)
+    mc := g.mountConfig
+    hpColor := colorCyan
+    if hpPercent < mc.SkillHPBelow {
+        hpColor = colorRed
+    }
+    vector.DrawFilledRect(screen, barX, y, barW, barH, color.RGBA{30, 30, 30, 255}, false)
+    vector.DrawFilledRect(screen, barX, y, barW*hpPercent, barH, hpColor, false)
+    if mc.SkillHPBelow > 0 {
+        skX := barX + barW*mc.SkillHPBelow
+        vector.StrokeLine(screen, skX, y, skX, y+barH, 2, colorOrange, false)
+    }
+    vector.StrokeRect(screen, barX, y, barW, barH, 1, colorBorder, false)
+    hpText := fmt.Sprintf("%d / %d", m.HP, m.MaxHP)
+    ebitenutil.DebugPrintAt(screen, hpText, int(barX)+int(barW/2)-len(hpText)*7/2, int(y)-1)
+
+    return y + 16 + 15
+}
+
+// kindStyle returns the radar color and panel letter for an entity kind.
+func kindStyle(k entity.Kind) (color.RGBA, string) {
+    switch k {
+    case entity.KindPlayer:
+        return colorRed, "P"
+    case entity.KindMount:
+        return colorCyan, "M"
+    case entity.KindPet:
+        return colorPurple, "T"
+    case entity.KindGatherable:
+        return colorOrange, "G"
+    case entity.KindObject:
+        return colorBlue, "O"
+    }
+    return colorYellow, "N"
+}
+
+// fade scales c (premultiplied) to alpha a in [0, 1].
+func fade(c color.RGBA, a float32) color.RGBA {
+    if a < 0 {
+        a = 0
+    }
+    return color.RGBA{uint8(float32(c.R) * a), uint8(float32(c.G) * a), uint8(float32(c.B) * a), uint8(float32(c.A) * a)}
+}
+
+// drawHeightArrow draws a chevron above (up) or below the dot at x, y.
+func drawHeightArrow(screen *ebiten.Image, x, y, dotR float32, up bool, c color.RGBA) {
+    tip, base := y-dotR-9, y-dotR-4
+    if !up {
+        tip, base = y+dotR+9, y+dotR+4
+    }
+    vector.StrokeLine(screen, x-4, base, x, tip, 2, c, false)
+    vector.StrokeLine(screen, x+4, base, x, tip, 2, c, false)
+}
+
+// relationStyle returns the color and letter for a relationship; neutral
+// entities keep the kind style.
+func relationStyle(r entity.Relationship) (color.RGBA, string, bool) {
+    switch r {
+    case entity.RelEnemy:
+        return colorEnemy, "E", true
+    case entity.RelFriend:
+        return colorFriend, "F", true
+    case entity.RelParty:
+        return colorParty, "Y", true
+    case entity.RelGuild:
+        return colorGuild, "U", true
+    }
+    return color.RGBA{}, "", false
+}
+
+func entityStyle(e entity.Tracked) (color.RGBA, string) {
+    c, letter := kindStyle(e.Kind)
+    if rc, rl, ok := relationStyle(e.Relation); ok {
+        c, letter = rc, letter+rl
+    }
+    return c, letter
+}
+
+// relationRank ordena o painel: inimigos primeiro, amigos por último
+func relationRank(r entity.Relationship) int {
+    switch r {
+    case entity.RelEnemy:
+        return 0
+    case entity.RelGuild:
+        return 2
+    case entity.RelParty:
+        return 3
+    case entity.RelFriend:
+        return 4
+    }
+    return 1
+}
+
+// visibleEntities applies the hide-friendly toggle.
+func (g *Game) visibleEntities(entities []entity.Tracked) []entity.Tracked {
+    if !g.hideFriendly {
+        return entities
+    }
+    out := make([]entity.Tracked, 0, len(entities))
+    for _, e := range entities {
+        if !e.Relation.Friendly() {
+            out = append(out, e)
+        }
+    }
+    return out
+}
+
+func (g *Game) drawRadar(screen *ebiten.Image, player entity.Entity, entities []entity.Tracked, centerX, centerY float32) {
     radius := float32(config.RADAR_RADIUS)
+    v := &g.radar
+    v.centerX, v.centerY, v.radius = centerX, centerY, radius
+    heading := g.radarHeading(player)
 
-    // Radar background circles
+    // Radar background
     ui.DrawCircle(screen, centerX, centerY, radius, color.RGBA{35, 40, 50, 255})
-    ui.DrawCircle(screen, centerX, centerY, radius*0.66, color.RGBA{30, 35, 45, 255})
-    ui.DrawCircle(screen, centerX, centerY, radius*0.33, color.RGBA{28, 32, 42, 255})
+
+    // Anéis de distância, em metros a partir do centro
+    for _, r := range v.rangeRings() {
+        ringR := r * v.scale()
+        vector.StrokeCircle(screen, centerX, centerY, ringR, 1, color.RGBA{50, 56, 68, 255}, false)
+        label := fmt.Sprintf("%.0fm", r)
+        ebitenutil.DebugPrintAt(screen, label, int(centerX)+3, int(centerY-ringR)+2)
+    }
 
     // Cross lines
     vector.StrokeLine(screen, centerX-radius, centerY, centerX+radius, centerY, 1, color.RGBA{45, 50, 60, 255}, false)
     vector.StrokeLine(screen, centerX, centerY-radius, centerX, centerY+radius, 1, color.RGBA{45, 50, 60, 255}, false)
 
-    // Player dot (center)
-    vector.DrawFilledCircle(screen, centerX, centerY, 6, colorGreen, false)
+    // Norte na borda (só sai do topo com o radar girando)
+    nx, ny := v.project(0, v.Range, heading)
+    nLen := float32(math.Hypot(float64(nx), float64(ny)))
+    nx, ny = nx/nLen*(radius-10), ny/nLen*(radius-10)
+    ebitenutil.DebugPrintAt(screen, "N", int(centerX+nx)-3, int(centerY+ny)-8)
+
+    // Player dot (fora do centro quando o radar foi arrastado)
+    px, py := v.project(-v.PanX, -v.PanY, heading)
+    if px*px+py*py <= radius*radius {
+        vector.DrawFilledCircle(screen, centerX+px, centerY+py, 6, colorGreen, false)
+    }
 
     // Entities
-    scale := radius / float32(config.RADAR_RANGE)
     playerCount := 0
     npcCount := 0
+    viewX, viewY := player.PosX+v.PanX, player.PosY+v.PanY
 
-    for _, e := range entities {
-        if e.Distance > config.RADAR_RANGE {
+    opts := g.radarOptions
+    now := time.Now()
+    visible := g.visibleEntities(entities)
+
+    // Rastros primeiro, para ficarem embaixo dos pontos
+    for _, e := range visible {
+        if !opts.trail(e.Kind) {
             continue
         }
+        dotColor, _ := entityStyle(e)
+        trail := append(e.Trail(), entity.TrailPoint{X: e.PosX, Y: e.PosY, Z: e.PosZ, Time: now})
+        for i := 1; i < len(trail); i++ {
+            age := float32(now.Sub(trail[i-1].Time).Seconds())
+            if age > opts.TrailSeconds {
+                continue
+            }
+            ax, ay := v.project(trail[i-1].X-viewX, trail[i-1].Y-viewY, heading)
+            bx, by := v.project(trail[i].X-viewX, trail[i].Y-viewY, heading)
+            if ax*ax+ay*ay > radius*radius || bx*bx+by*by > radius*radius {
+                continue
+            }
+            vector.StrokeLine(screen, centerX+ax, centerY+ay, centerX+bx, centerY+by, 2, fade(dotColor, 1-age/opts.TrailSeconds), false)
+        }
+    }
 
-        dx := e.PosX - player.PosX
-        dy := e.PosY - player.PosY
-        radarX := centerX + dx*scale
-        radarY := centerY - dy*scale
-
-        distFromCenter := (radarX-centerX)*(radarX-centerX) + (radarY-centerY)*(radarY-centerY)
-        if distFromCenter > radius*radius {
+    for _, e := range visible {
+        ex, ey := v.project(e.PosX-viewX, e.PosY-viewY, heading)
+        if ex*ex+ey*ey > radius*radius {
             continue
         }
+        radarX, radarY := centerX+ex, centerY+ey
 
-        var dotColor color.RGBA
+        dotColor, _ := entityStyle(e)
         if e.IsPlayer {
-            dotColor = colorRed
             playerCount++
         } else {
-            dotColor = colorYellow
             npcCount++
         }
-        vector.DrawFilledCircle(screen, radarX, radarY, 5, dotColor, false)
+        dotR := float32(5)
+        if e.Relation == entity.RelEnemy {
+            dotR = 7
+        }
+        vector.DrawFilledCircle(screen, radarX, radarY, dotR, dotColor, false)
 
-        if e.Distance < 50 {
-            ebitenutil.DebugPrintAt(screen, ui.TruncStr(e.Name, 10), int(radarX)+8, int(radarY)-4)
+        // Seta para quem está bem acima ou abaixo do player (penhascos,
+        // navios, gliders)
+        if dz := e.PosZ - player.PosZ; opts.height(e.Kind) && float32(math.Abs(float64(dz))) >= opts.HeightThreshold {
+            drawHeightArrow(screen, radarX, radarY, dotR, dz > 0, dotColor)
+        }
+
+        // Watchlist: anel na cor da entrada e nome sempre visível
+        hlColor, watched := g.watchlist.Highlight(e.Name)
+        if watched {
+            vector.StrokeCircle(screen, radarX, radarY, 10, 2, hlColor, false)
+        }
+
+        // Nomes de quem está perto: 50m no zoom padrão, proporcional ao zoom
+        if e.Distance < v.Range*0.05 || watched {
+            ui.DrawText(screen, ui.TruncStr(e.Name, 10), int(radarX)+8, int(radarY)-4)
         }
     }
 
     // Radar info
-    ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Range: %dm", config.RADAR_RANGE), int(centerX-radius)+5, int(centerY+radius)+10)
+    info := fmt.Sprintf("Range: %.0fm  Scan: %.0fm", v.Range, v.scanRange())
+    if v.PanX != 0 || v.PanY != 0 {
+        info += "  (arrastado, botão direito centraliza)"
+    }
+    ebitenutil.DebugPrintAt(screen, info, int(centerX-radius)+5, int(centerY+radius)+10)
     ebitenutil.DebugPrintAt(screen, fmt.Sprintf("P:%d  N:%d", playerCount, npcCount), int(centerX+radius)-60, int(centerY+radius)+10)
 }
 
-func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x, y, w float32) {
+func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Tracked, x, y, w float32) {
     panelH := float32(680)
 
     // Background
@@ -350,9 +605,30 @@ func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x,
     currentY := y + padding
 
     // === NEARBY ENTITIES ===
+    // Inimigos no topo, amigos no fim; dentro de cada grupo por distância
+    entities = append([]entity.Tracked(nil), g.visibleEntities(entities)...)
+    sort.SliceStable(entities, func(i, j int) bool {
+        ri, rj := relationRank(entities[i].Relation), relationRank(entities[j].Relation)
+        if ri != rj {
+            return ri < rj
+        }
+        return entities[i].Distance < entities[j].Distance
+    })
+    g.entityRows = g.entityRows[:0]
+
     g.drawSectionHeader(screen, fmt.Sprintf("NEARBY ENTITIES (%d)", len(entities)), innerX, currentY, innerW)
     currentY += 25
 
+    g.mutex.RLock()
+    prog, scanning := g.scanProgress, g.scanCancel != nil
+    g.mutex.RUnlock()
+    if scanning {
+        ebitenutil.DebugPrintAt(screen, fmt.Sprintf("scan %3.0f%%  %d MB  %d workers", prog.Percent(), prog.TotalBytes>>20, prog.Workers), int(innerX), int(currentY))
+    } else if prog.TotalBytes > 0 {
+        ebitenutil.DebugPrintAt(screen, fmt.Sprintf("scan %v  %d MB  %d workers", prog.Elapsed.Round(time.Millisecond), prog.TotalBytes>>20, prog.Workers), int(innerX), int(currentY))
+    }
+    currentY += 16
+
     if len(entities) == 0 {
         ebitenutil.DebugPrintAt(screen, "(none)", int(innerX), int(currentY))
         currentY += 16
@@ -365,25 +641,32 @@ func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x,
                 break
             }
 
-            typeColor := colorYellow
-            typeChar := "N"
-            if e.IsPlayer {
-                typeColor = colorRed
-                typeChar = "P"
-            }
+            typeColor, typeChar := entityStyle(e)
 
             vector.DrawFilledCircle(screen, innerX+6, currentY+6, 4, typeColor, false)
-            ebitenutil.DebugPrintAt(screen, fmt.Sprintf("[%s] %-12s %3.0fm", typeChar, ui.TruncStr(e.Name, 12), e.Distance), int(innerX)+14, int(currentY))
+            ui.DrawText(screen, fmt.Sprintf("[%-2s] %-12s %3.0fm", typeChar, ui.TruncStr(e.Name, 12), e.Distance), int(innerX)+14, int(currentY))
+            g.entityRows = append(g.entityRows, entityRow{x: innerX, y: currentY, w: innerW, h: 15, name: e.Name, relation: e.Relation})
             currentY += 15
         }
     }
     currentY += 25
 
+    if g.showStats {
+        g.drawReactionStats(screen, innerX, currentY, innerW)
+        return
+    }
+
     // === EVENTS ===
     g.drawSectionHeader(screen, "EVENTS (!! = reacted)", innerX, currentY, innerW)
     currentY += 25
 
-    allEvents := make([]string, 0)
+    // Os três logs juntos em ordem de hora, para o corte dos últimos 20
+    // mostrar os mais recentes de qualquer um deles
+    type eventLine struct {
+        at   time.Time
+        text string
+    }
+    allEvents := make([]eventLine, 0)
 
     for _, ev := range g.debuffMonitor.Events {
         prefix := ev.Type
@@ -394,7 +677,7 @@ func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x,
         if ev.CCName != "" {
             line += " " + ev.CCName
         }
-        allEvents = append(allEvents, line)
+        allEvents = append(allEvents, eventLine{ev.Time, line})
     }
 
     for _, ev := range g.buffMonitor.Events {
@@ -406,9 +689,17 @@ func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x,
         if ev.Name != "" {
             line += " " + ev.Name
         }
-        allEvents = append(allEvents, line)
+        allEvents = append(allEvents, eventLine{ev.Time, line})
     }
 
+    for _, a := range g.watchlist.RecentAlerts() {
+        allEvents = append(allEvents, eventLine{a.Time, fmt.Sprintf("[%s] !W %s %.0fm", a.Time.Format("15:04:05"), a.Name, a.Distance)})
+    }
+
+    sort.SliceStable(allEvents, func(i, j int) bool {
+        return allEvents[i].at.Before(allEvents[j].at)
+    })
+
     if len(allEvents) == 0 {
         ebitenutil.DebugPrintAt(screen, "(none)", int(innerX), int(currentY))
     } else {
@@ -418,12 +709,61 @@ func (g *Game) drawRightPanel(screen *ebiten.Image, entities []entity.Entity, x,
             startIdx = len(allEvents) - maxShow
         }
         for i := startIdx; i < len(allEvents); i++ {
-            ebitenutil.DebugPrintAt(screen, ui.TruncStr(allEvents[i], 45), int(innerX), int(currentY))
+            ui.DrawText(screen, ui.TruncStr(allEvents[i].text, 45), int(innerX), int(currentY))
             currentY += 14
         }
     }
 }
 
+// drawReactionStats mostra, por regra, quantas reações resolveram o CC/buff
+// dentro da janela de verificação e em quanto tempo.
+func (g *Game) drawReactionStats(screen *ebiten.Image, x, y, w float32) {
+    g.drawSectionHeader(screen, fmt.Sprintf("REACTIONS (ok em %v)", monitor.VerifyWindow), x, y, w)
+    y += 25
+
+    stats := g.debuffMonitor.Stats.Rules()
+    if len(stats) == 0 {
+        ebitenutil.DebugPrintAt(screen, "(none)", int(x), int(y))
+        return
+    }
+
+    ebitenutil.DebugPrintAt(screen, "regra            ok/total   taxa   média", int(x), int(y))
+    y += 16
+
+    maxShow := 20
+    for i, rs := range stats {
+        if i >= maxShow {
+            ebitenutil.DebugPrintAt(screen, fmt.Sprintf("+%d more...", len(stats)-maxShow), int(x), int(y))
+            break
+        }
+
+        rate, avg := "  -", "   -"
+        rateColor := colorTextDim
+        if r, ok := rs.SuccessRate(); ok {
+            rate = fmt.Sprintf("%3.0f%%", r*100)
+            switch {
+            case r >= 0.8:
+                rateColor = colorGreen
+            case r >= 0.5:
+                rateColor = colorYellow
+            default:
+                rateColor = colorRed
+            }
+        }
+        if rs.Successes > 0 {
+            avg = fmt.Sprintf("%4dms", rs.AvgClear().Milliseconds())
+        }
+
+        vector.DrawFilledRect(screen, x, y+2, 8, 8, rateColor, false)
+        name := ui.TruncStr(rs.Rule, 14)
+        if rs.Source == monitor.SourceBuff {
+            name = ui.TruncStr("B:"+rs.Rule, 14)
+        }
+        ui.DrawText(screen, fmt.Sprintf("%-14s %3d/%-3d  %s  %s", name, rs.Successes, rs.Successes+rs.Failures, rate, avg), int(x)+12, int(y))
+        y += 14
+    }
+}
+
 func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
     x := float32(10)
     w := float32(config.SCREEN_WIDTH - 20)
@@ -437,7 +777,7 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
     currentY := y + padding
 
     // Title
-    ebitenutil.DebugPrintAt(screen, "=== CONFIGURATION ===   [F3] CC Break  |  [F4] Buff Break  |  [F5] Buff Freeze", int(innerX), int(currentY))
+    ebitenutil.DebugPrintAt(screen, "=== CONFIGURATION ===   [F3] CC Break  |  [F4] Buff Break  |  [F5] Buff Freeze  |  [F6] Friendly  |  [F7] Rotate  |  [F8] Stats", int(innerX), int(currentY))
     currentY += 25
 
     // === ROW 1: Toggle Buttons ===
@@ -447,6 +787,10 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
     g.buffMonitorBtn.Y = currentY
     g.buffBreakBtn.Y = currentY
     g.buffFreezeBtn.Y = currentY
+    g.friendlyBtn.Y = currentY
+    g.rotateBtn.Y = currentY
+    g.statsBtn.Y = currentY
+    g.reportBtn.Y = currentY
 
     // Draw all buttons
     btnColor := color.RGBA{40, 80, 40, 255}
@@ -467,7 +811,7 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
 
     ccBtnColor := color.RGBA{80, 40, 80, 255}
     ccHoverColor := color.RGBA{100, 50, 100, 255}
-    if !g.debuffMonitor.CCWhitelist.Enabled {
+    if !g.debuffMonitor.BreakEnabled {
         ccBtnColor = color.RGBA{60, 40, 40, 255}
         ccHoverColor = color.RGBA{80, 50, 50, 255}
     }
@@ -483,7 +827,7 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
 
     buffBrkColor := color.RGBA{80, 80, 40, 255}
     buffBrkHover := color.RGBA{100, 100, 50, 255}
-    if !g.buffMonitor.Whitelist.Enabled {
+    if !g.buffMonitor.BreakEnabled {
         buffBrkColor = color.RGBA{60, 40, 40, 255}
         buffBrkHover = color.RGBA{80, 50, 50, 255}
     }
@@ -497,6 +841,31 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
     }
     g.buffFreezeBtn.Draw(screen, freezeBtnColor, freezeHoverColor)
 
+    friendBtnColor := color.RGBA{50, 90, 50, 255}
+    friendHoverColor := color.RGBA{60, 110, 60, 255}
+    if g.hideFriendly {
+        friendBtnColor = color.RGBA{60, 50, 50, 255}
+        friendHoverColor = color.RGBA{80, 60, 60, 255}
+    }
+    g.friendlyBtn.Draw(screen, friendBtnColor, friendHoverColor)
+
+    rotBtnColor := color.RGBA{60, 60, 90, 255}
+    rotHoverColor := color.RGBA{75, 75, 110, 255}
+    if !g.radar.Rotate {
+        rotBtnColor = color.RGBA{60, 50, 50, 255}
+        rotHoverColor = color.RGBA{80, 60, 60, 255}
+    }
+    g.rotateBtn.Draw(screen, rotBtnColor, rotHoverColor)
+
+    statsBtnColor := color.RGBA{80, 60, 40, 255}
+    statsHoverColor := color.RGBA{100, 75, 50, 255}
+    if !g.showStats {
+        statsBtnColor = color.RGBA{60, 50, 50, 255}
+        statsHoverColor = color.RGBA{80, 60, 60, 255}
+    }
+    g.statsBtn.Draw(screen, statsBtnColor, statsHoverColor)
+    g.reportBtn.Draw(screen, color.RGBA{50, 60, 70, 255}, color.RGBA{65, 80, 95, 255})
+
     currentY += 35
 
     // === ROW 2: HP Potions (left) | Mana Potions (right) ===
@@ -563,8 +932,9 @@ func (g *Game) drawConfigPanel(screen *ebiten.Image, y, h float32) {
     currentY += 35
 
     // === ROW 3: Info ===
-    ebitenutil.DebugPrintAt(screen, fmt.Sprintf("CC Whitelist: %d entries  |  Buff Whitelist: %d entries",
-        len(g.debuffMonitor.CCWhitelist.Entries), len(g.buffMonitor.Whitelist.Entries)), int(innerX), int(currentY))
+    rules := g.debuffMonitor.Rules
+    ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Rules (%s): %d debuff  |  %d buff",
+        monitor.RulesFile, rules.Count(monitor.SourceDebuff), rules.Count(monitor.SourceBuff)), int(innerX), int(currentY))
 }
 
 func (g *Game) drawSectionHeader(screen *ebiten.Image, title string, x, y, w float32) {
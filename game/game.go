@@ -1,41 +1,53 @@
 package game
 
 import (
+    "context"
+    "errors"
     "fmt"
     "image/color"
     "muletinha/config"
+    "muletinha/diagnose"
     "muletinha/entity"
     "muletinha/input"
     "muletinha/memory"
     "muletinha/monitor"
     "muletinha/mount"
+    "muletinha/offsets"
     "muletinha/process"
+    "muletinha/sigscan"
     "muletinha/ui"
+    "muletinha/watch"
+    "os"
     "sync"
     "time"
-    "unsafe"
 
     "github.com/hajimehoshi/ebiten/v2"
     "github.com/hajimehoshi/ebiten/v2/inpututil"
-    "golang.org/x/sys/windows"
 )
 
 var (
-    debuffBuffer = make([]byte, 30*config.DEBUFF_SIZE)
-    buffBuffer   = make([]byte, 30*config.BUFF_SIZE)
+    debuffBuffer []byte
+    buffBuffer   []byte
 )
 
 type Game struct {
-    handle      windows.Handle
+    mem         memory.ProcessMemory
     x2game      uintptr
+    profile     *offsets.Profile
+    build       offsets.BuildID
+    unsupported string
     icudt42     uintptr
     localPlayer entity.Entity
     playerMount entity.Entity
-    entities    []entity.Entity
+    target      entity.Target
+    hasTarget   bool
+    tracker     *entity.Tracker
     mutex       sync.RWMutex
     connected   bool
     frameCount  int
     mountConfig *mount.MountConfig
+    watchlist   *watch.Watchlist
+    relations   *entity.NameLists
 
     autoPotEnabled  bool
     masterToggleBtn *ui.Button
@@ -60,26 +72,49 @@ type Game struct {
     buffFreezeValue   uint32
     buffFreezeBtn     *ui.Button
 
+    hideFriendly bool
+    friendlyBtn  *ui.Button
+    entityRows   []entityRow // linhas clicáveis do painel de entidades
+
+    radar        radarView
+    radarOptions *radarOptions
+    rotateBtn    *ui.Button
+
+    showStats bool // painel REACTIONS no lugar de EVENTS
+    statsBtn  *ui.Button
+    reportBtn *ui.Button
+
     mouseX, mouseY int
 
-    cachedDebuffBase   uintptr
-    cachedBuffListAddr uintptr
-    lastBaseCheck      time.Time
-    lastBuffCheck      time.Time
+    debuffList         *memory.CachedChain
+    buffList           *memory.CachedChain
     lastEntityScan     time.Time
     entityScanInterval time.Duration
-    scanningEntities   bool
+    scanCancel         context.CancelFunc // != nil enquanto um scan roda
+    scanProgress       entity.ScanProgress
+    classifierMod      time.Time // mtime do entity_classes.json carregado
+    retryProfile       bool      // sem perfil para a build; testa os offsets padrão de novo
+    lastProfileTry     time.Time
+    lastAttachCheck    time.Time
+
+    replay    bool
+    capturing bool
 }
 
-func NewGame() *Game {
-
-    g := &Game{
+func newGame() *Game {
+    rules := monitor.NewRuleEngine()
+    stats := monitor.NewReactionStats()
+    return &Game{
         autoPotEnabled:     true,
-        debuffMonitor:      monitor.NewDebuffMonitor(),
-        buffMonitor:        monitor.NewBuffMonitor(),
+        debuffMonitor:      monitor.NewDebuffMonitor(rules, stats),
+        buffMonitor:        monitor.NewBuffMonitor(rules, stats),
         entityScanInterval: 1000 * time.Millisecond,
         mountConfig:        mount.NewMountConfig(),
-        entities:           make([]entity.Entity, 0, 100),
+        watchlist:          watch.NewWatchlist(),
+        relations:          loadRelations(),
+        radar:              newRadarView(),
+        radarOptions:       newRadarOptions(),
+        tracker:            entity.NewTracker(),
         buffFreezeEnabled:  false,
         buffFreezeValue:    0,
         masterToggleBtn: &ui.Button{
@@ -106,6 +141,22 @@ func NewGame() *Game {
             X: 550, Y: 0, W: 100, H: 22,
             Label: "Freeze:OFF",
         },
+        friendlyBtn: &ui.Button{
+            X: 655, Y: 0, W: 100, H: 22,
+            Label: "Friendly:ON",
+        },
+        rotateBtn: &ui.Button{
+            X: 760, Y: 0, W: 100, H: 22,
+            Label: "Rotate:OFF",
+        },
+        statsBtn: &ui.Button{
+            X: 865, Y: 0, W: 100, H: 22,
+            Label: "Stats:OFF",
+        },
+        reportBtn: &ui.Button{
+            X: 970, Y: 0, W: 100, H: 22,
+            Label: "Report",
+        },
         // HP Potions
         desertFire: &ui.PotionConfig{
             Name:      "Desert Fire",
@@ -164,6 +215,10 @@ func NewGame() *Game {
 			ToggleBtn: &ui.Button{X: 810, Y: 0, W: 50, H: 20, Label: "ON"},
 		},
     }
+}
+
+func NewGame() *Game {
+    g := newGame()
 
     pid, err := process.FindProcess("archeage.exe")
     if err != nil || pid == 0 {
@@ -171,38 +226,430 @@ func NewGame() *Game {
         return g
     }
 
-    handle, err := windows.OpenProcess(0x1F0FFF, false, pid)
+    mem, err := memory.OpenProcess(pid)
     if err != nil {
         fmt.Println("Erro ao abrir processo:", err)
         return g
     }
 
-    x2game, err := process.GetModuleBase(pid, "x2game.dll")
+    x2game, err := process.GetModule(pid, "x2game.dll")
     if err != nil {
         fmt.Println("x2game.dll não encontrado!")
-        windows.CloseHandle(handle)
+        mem.Close()
         return g
     }
 
     icudt42, err := process.GetModuleBase(pid, "icudt42.dll")
     if err != nil {
         fmt.Println("icudt42.dll não encontrado!")
-        windows.CloseHandle(handle)
+        mem.Close()
         return g
     }
 
-    g.handle = handle
-    g.x2game = x2game
+    g.mem = mem
+    g.x2game = x2game.Base
     g.icudt42 = icudt42
     g.connected = true
 
-    fmt.Printf("[INFO] x2game.dll base: %08X\n", x2game)
+    fmt.Printf("[INFO] x2game.dll base: %08X\n", x2game.Base)
+    refreshAddressMap(mem)
+    g.build = offsets.DetectBuild(mem, x2game.Base, x2game.Path)
+    g.selectProfile()
 
     return g
 }
 
-func (g *Game) GetHandle() windows.Handle {
-    return g.handle
+// selectProfile ativa o perfil de offsets da build do x2game.dll. Sem perfil,
+// o overlay fica em "build não suportada" e não lê nada; os offsets padrão
+// são testados de novo a cada profileRetryInterval (na tela de login o
+// localplayer ainda não existe).
+const profileRetryInterval = 5 * time.Second
+
+func (g *Game) selectProfile() {
+    g.lastProfileTry = time.Now()
+
+    prof, _, err := diagnose.SelectProfile(g.mem, g.x2game, g.build)
+    g.retryProfile = errors.Is(err, offsets.ErrUnsupportedBuild) && !g.replay
+    if err != nil {
+        if err.Error() != g.unsupported {
+            fmt.Printf("[OFFSETS] %v\n", err)
+        }
+        g.unsupported = err.Error()
+        return
+    }
+
+    if len(prof.Signatures) > 0 {
+        mod, err := sigscan.ReadModule(g.mem, g.x2game)
+        if err == nil {
+            err = prof.ApplySignatures(mod)
+        }
+        if err != nil {
+            g.unsupported = fmt.Sprintf("%s: %v", prof.Name, err)
+            fmt.Printf("[OFFSETS] Assinaturas do perfil %s falharam: %v\n", prof.Name, err)
+            return
+        }
+    }
+
+    // Layouts com tag `mem` que usam nomes do perfil
+    for _, v := range []any{entity.Entity{}, entity.Target{}, monitor.BuffInfo{}, monitor.DebuffInfo{}} {
+        if _, err := memory.Span(v, prof); err != nil {
+            g.unsupported = fmt.Sprintf("%s: %v", prof.Name, err)
+            fmt.Printf("[OFFSETS] Perfil %s incompleto: %v\n", prof.Name, err)
+            return
+        }
+    }
+
+    g.profile = prof
+    offsets.SetActive(prof)
+    g.debuffList = memory.NewCachedChain(prof.Chains.DebuffList, 50*time.Millisecond)
+    g.buffList = memory.NewCachedChain(prof.Chains.DebuffList, 100*time.Millisecond)
+    debuffBuffer = make([]byte, 30*prof.Debuff.Size)
+    buffBuffer = make([]byte, 30*prof.Buff.Size)
+
+    fmt.Printf("[OFFSETS] Perfil %s (%s) para build %s\n", prof.Name, prof.File, g.build)
+
+    // Vtables relativas ao x2game.dll, mais as já confirmadas nesta build
+    known, err := entity.LoadVTableSet(entity.VTableFile(g.build))
+    if err != nil {
+        fmt.Printf("[VTABLE] %v\n", err)
+    }
+    filter, err := entity.NewVTableFilter(g.mem, g.x2game, prof, known)
+    if err != nil {
+        fmt.Printf("[VTABLE] Sem filtro de vtable, scanner desativado: %v\n", err)
+        return
+    }
+    entity.SetVTableFilter(filter)
+    if known != nil {
+        fmt.Printf("[VTABLE] RVA 0x%X-0x%X, %d conhecidas (restrito=%v)\n", filter.MinRVA, filter.MaxRVA, known.Len(), known.Enforced())
+    }
+}
+
+// startEntityScan varre o heap em paralelo. Entidades entram no tracker
+// conforme são encontradas; no fim as que não apareceram saem.
+func (g *Game) startEntityScan() {
+    ctx, cancel := context.WithCancel(context.Background())
+    player := g.localPlayer
+    mem := g.mem
+    maxDistance := g.radar.scanRange()
+
+    // Resultados de antes de um tracker.Clear (teleporte) são descartados
+    gen := g.tracker.Generation()
+
+    g.mutex.Lock()
+    g.scanCancel = cancel
+    g.mutex.Unlock()
+
+    go func() {
+        defer cancel()
+        refreshAddressMap(mem)
+        g.reloadClassifier()
+
+        entities, prog, err := entity.Scan(ctx, mem, player, entity.ScanOptions{
+            MaxDistance: maxDistance,
+            OnEntity: func(e entity.Entity) {
+                if f := entity.FilterEntities([]entity.Entity{e}, player); len(f) > 0 {
+                    g.tracker.Observe(gen, f[0], time.Now())
+                }
+            },
+            OnProgress: func(p entity.ScanProgress) {
+                g.mutex.Lock()
+                g.scanProgress = p
+                g.mutex.Unlock()
+            },
+        })
+
+        // Só um scan completo pode dizer quem saiu
+        if err == nil {
+            g.tracker.EndScan(gen, entity.FilterEntities(entities, player), time.Now())
+            g.learnVTables(player)
+        }
+
+        g.mutex.Lock()
+        g.scanCancel = nil
+        g.mutex.Unlock()
+
+        if err != nil && !errors.Is(err, context.Canceled) {
+            fmt.Printf("[SCAN] Erro: %v\n", err)
+        } else if err != nil {
+            fmt.Printf("[SCAN] Cancelado após %v (%.0f%%)\n", prog.Elapsed.Round(time.Millisecond), prog.Percent())
+        }
+    }()
+}
+
+// Tempo no tracker para a vtable de uma entidade entrar no conjunto conhecido
+const vtableConfirmTime = 10 * time.Second
+
+// learnVTables grava as vtables de entidades confirmadas: o próprio player e
+// quem sobreviveu a scans e refreshes por vtableConfirmTime.
+func (g *Game) learnVTables(player entity.Entity) {
+    f := entity.ActiveVTableFilter()
+    if g.replay || f == nil || f.Known == nil {
+        return
+    }
+
+    learned := 0
+    if entity.Learn(player) {
+        learned++
+    }
+    now := time.Now()
+    for _, e := range g.tracker.Entities() {
+        if now.Sub(e.FirstSeen) >= vtableConfirmTime && entity.Learn(e.Entity) {
+            learned++
+        }
+    }
+    if learned == 0 {
+        return
+    }
+
+    if err := f.Known.Save(); err != nil {
+        fmt.Printf("[VTABLE] Erro ao salvar: %v\n", err)
+        return
+    }
+    fmt.Printf("[VTABLE] %d vtable(s) nova(s), %d conhecidas\n", learned, f.Known.Len())
+}
+
+// entityRow é a área de uma linha do painel NEARBY ENTITIES, registrada no
+// Draw para o clique no Update.
+type entityRow struct {
+    x, y, w, h float32
+    name       string
+    relation   entity.Relationship
+}
+
+func loadRelations() *entity.NameLists {
+    rel, err := entity.LoadNameLists(entity.RelationsFile)
+    if err != nil {
+        fmt.Printf("[REL] Erro: %v\n", err)
+        return nil
+    }
+    entity.SetRelater(rel)
+    return rel
+}
+
+// cycleRelation troca a relação de name pela próxima da lista e grava o
+// arquivo; o tracker é reclassificado na hora.
+func (g *Game) cycleRelation(name string, current entity.Relationship) {
+    if g.relations == nil {
+        return
+    }
+    next := current.Next()
+    if err := g.relations.Set(name, next); err != nil {
+        fmt.Printf("[REL] Erro ao salvar: %v\n", err)
+    }
+    g.tracker.Relabel()
+    fmt.Printf("[REL] %s: %s\n", name, next)
+}
+
+func (g *Game) toggleHideFriendly() {
+    g.hideFriendly = !g.hideFriendly
+    if g.hideFriendly {
+        g.friendlyBtn.Label = "Friendly:OFF"
+    } else {
+        g.friendlyBtn.Label = "Friendly:ON"
+    }
+}
+
+func (g *Game) toggleRadarRotate() {
+    g.radar.Rotate = !g.radar.Rotate
+    if g.radar.Rotate {
+        g.rotateBtn.Label = "Rotate:ON"
+        if g.profile != nil && g.profile.Entity.Heading == 0 {
+            fmt.Println("[RADAR] Perfil sem entity.heading, o radar continua com o norte para cima")
+        }
+    } else {
+        g.rotateBtn.Label = "Rotate:OFF"
+    }
+}
+
+func (g *Game) toggleStats() {
+    g.showStats = !g.showStats
+    if g.showStats {
+        g.statsBtn.Label = "Stats:ON"
+    } else {
+        g.statsBtn.Label = "Stats:OFF"
+    }
+}
+
+// exportReport grava as estatísticas de reação em reports/.
+func (g *Game) exportReport() {
+    filename, err := g.debuffMonitor.Stats.WriteReport(time.Now())
+    if err != nil {
+        fmt.Printf("[STATS] Erro ao salvar relatório: %v\n", err)
+        return
+    }
+    fmt.Printf("[STATS] Relatório salvo em %s\n", filename)
+}
+
+// radarHeading é o ângulo do radar: o heading do player com Rotate ligado e
+// um perfil que o lê, senão norte para cima.
+func (g *Game) radarHeading(player entity.Entity) float32 {
+    if !g.radar.Rotate || g.profile == nil || g.profile.Entity.Heading == 0 {
+        return 0
+    }
+    return player.Facing
+}
+
+// handleRadarInput: roda do mouse dá zoom, arrastar com o botão esquerdo
+// desloca o radar e o botão direito volta a centralizar no player.
+func (g *Game) handleRadarInput() {
+    v := &g.radar
+    over := v.contains(g.mouseX, g.mouseY)
+
+    if _, wy := ebiten.Wheel(); wy != 0 && over {
+        v.zoom(wy)
+    }
+
+    if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && over {
+        v.dragging = true
+        v.dragX, v.dragY = g.mouseX, g.mouseY
+    }
+    if v.dragging {
+        if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+            v.dragging = false
+        } else {
+            g.mutex.RLock()
+            heading := g.radarHeading(g.localPlayer)
+            g.mutex.RUnlock()
+            v.drag(g.mouseX, g.mouseY, heading)
+        }
+    }
+
+    if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && over {
+        v.recenter()
+    }
+}
+
+// reloadClassifier (re)carrega as regras de classificação quando o arquivo
+// muda, para corrigir classificações sem reiniciar.
+func (g *Game) reloadClassifier() {
+    info, err := os.Stat(entity.ClassifierFile)
+
+    g.mutex.Lock()
+    defer g.mutex.Unlock()
+    if err == nil && info.ModTime().Equal(g.classifierMod) {
+        return
+    }
+
+    c, err := entity.LoadClassifier(entity.ClassifierFile)
+    if err != nil {
+        // Mantém as regras anteriores até o arquivo ser corrigido
+        fmt.Printf("[CLASS] Erro: %v\n", err)
+        if info != nil {
+            g.classifierMod = info.ModTime()
+        }
+        return
+    }
+    entity.SetClassifier(c)
+    if info, err := os.Stat(entity.ClassifierFile); err == nil {
+        g.classifierMod = info.ModTime()
+    }
+}
+
+// handleEntityEvents registra no console jogadores entrando/saindo do alcance.
+func (g *Game) handleEntityEvents(events []entity.Event) {
+    for _, ev := range events {
+        if !ev.Entity.IsPlayer {
+            continue
+        }
+        switch ev.Type {
+        case entity.Appeared:
+            fmt.Printf("[TRACK] + %s (%.0fm)\n", ev.Entity.Name, ev.Entity.Distance)
+        case entity.Left:
+            fmt.Printf("[TRACK] - %s\n", ev.Entity.Name)
+        case entity.Died:
+            fmt.Printf("[TRACK] x %s morreu\n", ev.Entity.Name)
+        }
+    }
+}
+
+// checkTeleport compara a posição do player com a do frame anterior. Um
+// salto maior que TELEPORT_DISTANCE (ou outro personagem) invalida o scan em
+// andamento e o tracker, que partem da posição antiga; com ou sem scan
+// rodando, o próximo começa na hora.
+func (g *Game) checkTeleport(prev, cur entity.Entity) {
+    if prev.Address == 0 || cur.Address == 0 {
+        return
+    }
+    if prev.Address == cur.Address &&
+        memory.CalculateDistance(prev.PosX, prev.PosY, prev.PosZ, cur.PosX, cur.PosY, cur.PosZ) <= config.TELEPORT_DISTANCE {
+        return
+    }
+
+    fmt.Println("[SCAN] Teleporte detectado, reiniciando varredura")
+    g.cancelEntityScan()
+    g.lastEntityScan = time.Time{}
+
+    // As entidades em volta da posição antiga não valem mais (Refresh as
+    // manteria vivas); as novas entram pelo próximo scan, sem eventos de
+    // saída falsos
+    g.tracker.Clear()
+}
+
+func (g *Game) cancelEntityScan() {
+    g.mutex.RLock()
+    cancel := g.scanCancel
+    g.mutex.RUnlock()
+    if cancel != nil {
+        cancel()
+    }
+}
+
+// refreshAddressMap atualiza o mapa de memória usado por IsValidPtr. O heap
+// cresce durante o jogo, então é refeito a cada varredura de entidades.
+func refreshAddressMap(mem memory.ProcessMemory) {
+    m, err := memory.LoadAddressMap(mem)
+    if err != nil {
+        fmt.Printf("[MEM] Não foi possível enumerar regiões: %v\n", err)
+        return
+    }
+    memory.SetAddressMap(m)
+}
+
+// Intervalo entre as verificações de que o cliente continua aberto
+const attachCheckInterval = time.Second
+
+// checkAttached relê o header do x2game.dll. Se a leitura falha, o cliente
+// fechou (ou descarregou o módulo) e o overlay se desconecta.
+func (g *Game) checkAttached() {
+    g.lastAttachCheck = time.Now()
+
+    var mz [2]byte
+    if err := memory.ReadMemoryBytes(g.mem, g.x2game, mz[:]); err == nil && mz[0] == 'M' && mz[1] == 'Z' {
+        return
+    }
+    fmt.Println("[INFO] ArcheAge fechou, desconectado")
+    g.detach()
+}
+
+// detach para o scan em andamento, esquece o perfil e o que foi lido do
+// processo e fecha o backend.
+func (g *Game) detach() {
+    g.cancelEntityScan()
+    g.tracker.Clear()
+    offsets.SetActive(nil)
+    entity.SetVTableFilter(nil)
+
+    g.mutex.Lock()
+    g.connected = false
+    g.profile = nil
+    g.retryProfile = false
+    g.localPlayer = entity.Entity{}
+    g.playerMount = entity.Entity{}
+    g.hasTarget = false
+    g.mutex.Unlock()
+
+    if g.mem != nil {
+        g.mem.Close()
+        g.mem = nil
+    }
+}
+
+// Close releases the memory backend attached to the game process.
+func (g *Game) Close() {
+    g.cancelEntityScan()
+    if g.mem != nil {
+        g.mem.Close()
+    }
 }
 
 func sendKeyPotion(combo input.KeyCombo) {
@@ -272,85 +719,23 @@ func (g *Game) checkAndUsePotion() {
 }
 
 func (g *Game) getDebuffBaseFast() uintptr {
-    if time.Since(g.lastBaseCheck) < 50*time.Millisecond && g.cachedDebuffBase != 0 {
-        return g.cachedDebuffBase
-    }
-
-    ptr1 := memory.ReadU32(g.handle, g.x2game+config.PTR_LOCALPLAYER)
-    if ptr1 == 0 {
-        return 0
-    }
-    ptr2 := memory.ReadU32(g.handle, uintptr(ptr1)+config.PTR_ENTITY)
-    if ptr2 == 0 {
-        return 0
-    }
-    entityBase := memory.ReadU32(g.handle, uintptr(ptr2)+config.OFF_ENTITY_BASE)
-    if entityBase == 0 {
-        return 0
-    }
-    debuffBase := memory.ReadU32(g.handle, uintptr(entityBase)+config.OFF_DEBUFF_PTR)
-    if debuffBase == 0 || !memory.IsValidPtr(debuffBase) {
-        return 0
-    }
-
-    g.cachedDebuffBase = uintptr(debuffBase)
-    g.lastBaseCheck = time.Now()
-    return g.cachedDebuffBase
+    addr, _ := g.debuffList.Resolve(g.mem, g.x2game)
+    return addr
 }
 
 func (g *Game) findBuffListFromPlayer() uintptr {
-    if time.Since(g.lastBuffCheck) < 100*time.Millisecond && g.cachedBuffListAddr != 0 {
-        return g.cachedBuffListAddr
-    }
-
-    ptr1 := memory.ReadU32(g.handle, g.x2game+config.PTR_LOCALPLAYER)
-    if ptr1 == 0 {
-        return 0
-    }
-    playerAddr := memory.ReadU32(g.handle, uintptr(ptr1)+config.PTR_ENTITY)
-    if playerAddr == 0 {
-        return 0
-    }
-
-    base := memory.ReadU32(g.handle, uintptr(playerAddr)+config.OFF_ENTITY_BASE)
-    if !memory.IsValidPtr(base) {
-        return 0
-    }
-
-    listPtr := memory.ReadU32(g.handle, uintptr(base)+config.OFF_DEBUFF_PTR)
-    if !memory.IsValidPtr(listPtr) {
-        return 0
-    }
-
-    g.cachedBuffListAddr = uintptr(listPtr)
-    g.lastBuffCheck = time.Now()
-    return g.cachedBuffListAddr
+    addr, _ := g.buffList.Resolve(g.mem, g.x2game)
+    return addr
 }
 
 // getBuffFreezeAddress resolves the pointer chain for buff freeze
 // x2game.dll+01325640 -> +0x4 -> +0x20 -> +0x8 -> +0x384
 func (g *Game) getBuffFreezeAddress() uintptr {
-    ptr1 := memory.ReadU32(g.handle, g.x2game+config.PTR_BUFF_FREEZE)
-    if ptr1 == 0 {
-        return 0
-    }
-
-    ptr2 := memory.ReadU32(g.handle, uintptr(ptr1)+uintptr(config.OFF_BUFF_FREEZE_PTR1))
-    if ptr2 == 0 {
-        return 0
-    }
-
-    ptr3 := memory.ReadU32(g.handle, uintptr(ptr2)+uintptr(config.OFF_BUFF_FREEZE_PTR2))
-    if ptr3 == 0 {
+    if g.profile == nil {
         return 0
     }
-
-    ptr4 := memory.ReadU32(g.handle, uintptr(ptr3)+uintptr(config.OFF_BUFF_FREEZE_PTR3))
-    if ptr4 == 0 {
-        return 0
-    }
-
-    return uintptr(ptr4) + uintptr(config.OFF_BUFF_FREEZE_FINAL)
+    addr, _ := g.profile.Chains.BuffFreeze.Resolve(g.mem, g.x2game)
+    return addr
 }
 
 // freezeBuffValue writes the frozen value to the buff count address
@@ -364,7 +749,7 @@ func (g *Game) freezeBuffValue() {
         return
     }
 
-    memory.WriteU32(g.handle, addr, g.buffFreezeValue)
+    memory.WriteU32(g.mem, addr, g.buffFreezeValue)
 }
 
 // readBuffFreezeValue reads the current value at the freeze address
@@ -373,7 +758,85 @@ func (g *Game) readBuffFreezeValue() uint32 {
     if addr == 0 {
         return 0
     }
-    return memory.ReadU32(g.handle, addr)
+    return memory.ReadU32(g.mem, addr)
+}
+
+// playerState is what the reaction rules' conditions look at.
+func (g *Game) playerState() monitor.PlayerState {
+    g.mutex.RLock()
+    defer g.mutex.RUnlock()
+    return monitor.PlayerState{
+        HP: g.localPlayer.HP, MaxHP: g.localPlayer.MaxHP,
+        MP: g.localPlayer.MP, MaxMP: g.localPlayer.MaxMP,
+        Mounted: g.playerMount.Address != 0,
+    }
+}
+
+// stackLabel acrescenta os acúmulos ao nome do evento quando o perfil os lê.
+func stackLabel(name string, stack uint32) string {
+    if stack == 0 {
+        return name
+    }
+    return fmt.Sprintf("%s x%d", name, stack)
+}
+
+// reactBuffChange roda os triggers refreshed, stack_changed e expiring de um
+// buff já conhecido.
+func (g *Game) reactBuffChange(state *monitor.EntryState, ctx monitor.Context, duration uint32, name string) {
+    refreshed, stacked := state.Update(ctx.TimeLeft, duration, ctx.Stack)
+    if refreshed {
+        ctx.Trigger = monitor.TriggerRefreshed
+        reacted, rule, action := g.buffMonitor.React(ctx)
+        if reacted {
+            fmt.Printf("[BUFF] %s (ID:%d) reaplicado -> %s\n", rule.Name, ctx.ID, action.Key)
+        }
+        g.buffMonitor.AddEvent("R", ctx.ID, stackLabel(name, ctx.Stack), reacted)
+    }
+    if stacked {
+        ctx.Trigger = monitor.TriggerStackChanged
+        reacted, rule, action := g.buffMonitor.React(ctx)
+        if reacted {
+            fmt.Printf("[BUFF] %s (ID:%d) x%d -> %s\n", rule.Name, ctx.ID, ctx.Stack, action.Key)
+        }
+        g.buffMonitor.AddEvent("S", ctx.ID, stackLabel(name, ctx.Stack), reacted)
+    }
+    if !state.Expiring {
+        ctx.Trigger = monitor.TriggerExpiring
+        if reacted, rule, action := g.buffMonitor.React(ctx); reacted {
+            state.Expiring = true
+            fmt.Printf("[BUFF] %s (ID:%d) acabando -> %s\n", rule.Name, ctx.ID, action.Key)
+            g.buffMonitor.AddEvent("E", ctx.ID, name, true)
+        }
+    }
+}
+
+// reactDebuffChange é o mesmo para um debuff já conhecido.
+func (g *Game) reactDebuffChange(state *monitor.EntryState, ctx monitor.Context, duration, id uint32, name string) {
+    refreshed, stacked := state.Update(ctx.TimeLeft, duration, ctx.Stack)
+    if refreshed {
+        ctx.Trigger = monitor.TriggerRefreshed
+        reacted, rule, action := g.debuffMonitor.React(ctx)
+        if reacted {
+            fmt.Printf("[CC] %s (T:%d) reaplicado -> SPAM %s\n", rule.Name, ctx.ID, action.Key)
+        }
+        g.debuffMonitor.AddEvent("R", id, ctx.ID, stackLabel(name, ctx.Stack), reacted)
+    }
+    if stacked {
+        ctx.Trigger = monitor.TriggerStackChanged
+        reacted, rule, action := g.debuffMonitor.React(ctx)
+        if reacted {
+            fmt.Printf("[CC] %s (T:%d) x%d -> SPAM %s\n", rule.Name, ctx.ID, ctx.Stack, action.Key)
+        }
+        g.debuffMonitor.AddEvent("S", id, ctx.ID, stackLabel(name, ctx.Stack), reacted)
+    }
+    if !state.Expiring {
+        ctx.Trigger = monitor.TriggerExpiring
+        if reacted, rule, action := g.debuffMonitor.React(ctx); reacted {
+            state.Expiring = true
+            fmt.Printf("[CC] %s (T:%d) acabando -> SPAM %s\n", rule.Name, ctx.ID, action.Key)
+            g.debuffMonitor.AddEvent("E", id, ctx.ID, name, true)
+        }
+    }
 }
 
 func (g *Game) updateBuffsInstant() {
@@ -386,56 +849,61 @@ func (g *Game) updateBuffsInstant() {
         return
     }
 
+    p := g.profile.Buff
     g.buffMonitor.BuffListAddr = buffListAddr
-    count := memory.ReadU32(g.handle, buffListAddr+config.BUFF_COUNT_OFF)
+    var countBuf [4]byte
+    countErr := memory.ReadMemoryBytes(g.mem, buffListAddr+uintptr(p.Count), countBuf[:])
+    count := memory.BytesToUint32(countBuf[:])
     g.buffMonitor.RawCount = count
 
-    if count == 0 || count > 50 {
-        if len(g.buffMonitor.KnownIDs) > 0 {
-            for k := range g.buffMonitor.KnownIDs {
-                delete(g.buffMonitor.KnownIDs, k)
-            }
+    // Leitura falha ou lixo não é prova de que as entradas sumiram: só uma
+    // lista lida de verdade confirma as reações pendentes
+    if countErr != nil || count > 50 {
+        g.buffMonitor.Reset()
+        g.buffMonitor.Buffs = g.buffMonitor.Buffs[:0]
+        return
+    }
+    if count == 0 {
+        now := time.Now()
+        for k := range g.buffMonitor.KnownIDs {
+            g.buffMonitor.Remove(k, now)
         }
         g.buffMonitor.Buffs = g.buffMonitor.Buffs[:0]
         return
     }
 
-    arrayAddr := buffListAddr + config.BUFF_ARRAY_OFF
+    arrayAddr := buffListAddr + uintptr(p.Array)
 
-    totalSize := 30 * config.BUFF_SIZE
+    totalSize := 30 * int(p.Size)
     if totalSize > len(buffBuffer) {
         totalSize = len(buffBuffer)
     }
 
-    var bytesRead uintptr
-    ret, _, _ := memory.ProcReadProcessMemory.Call(
-        uintptr(g.handle),
-        uintptr(arrayAddr),
-        uintptr(unsafe.Pointer(&buffBuffer[0])),
-        uintptr(totalSize),
-        uintptr(unsafe.Pointer(&bytesRead)),
-    )
-
-    if ret == 0 {
+    bytesRead, err := g.mem.ReadBytes(arrayAddr, buffBuffer[:totalSize])
+    if err != nil {
         return
     }
 
     newBuffs := g.buffMonitor.Buffs[:0]
     currentIDs := make(map[uint32]bool, count)
+    player := g.playerState()
 
-    maxItems := int(bytesRead) / config.BUFF_SIZE
+    maxItems := bytesRead / int(p.Size)
     if maxItems > 30 {
         maxItems = 30
     }
 
     foundCount := 0
     for i := 0; i < maxItems && foundCount < int(count); i++ {
-        offset := i * config.BUFF_SIZE
+        offset := i * int(p.Size)
 
-        buffID := memory.BytesToUint32(buffBuffer[offset+config.BUFF_OFF_ID : offset+config.BUFF_OFF_ID+4])
-        duration := memory.BytesToUint32(buffBuffer[offset+config.BUFF_OFF_DUR : offset+config.BUFF_OFF_DUR+4])
-        timeLeft := memory.BytesToUint32(buffBuffer[offset+config.BUFF_OFF_LEFT : offset+config.BUFF_OFF_LEFT+4])
+        info := monitor.BuffInfo{Index: i}
+        entry := buffBuffer[offset : offset+int(p.Size)]
+        if memory.DecodeBytes(g.mem, entry, arrayAddr+uintptr(offset), &info, g.profile) != nil {
+            return
+        }
 
+        buffID := info.ID
         if buffID < 1000 || buffID > 9999999 {
             continue
         }
@@ -443,33 +911,34 @@ func (g *Game) updateBuffsInstant() {
         currentIDs[buffID] = true
         foundCount++
 
-        buffName := g.buffMonitor.Whitelist.GetName(buffID)
+        buffName := g.buffMonitor.Rules.Name(monitor.SourceBuff, buffID)
+        ctx := monitor.Context{ID: buffID, TimeLeft: info.TimeLeft, Stack: info.Stack, Player: player}
 
-        if !g.buffMonitor.KnownIDs[buffID] {
-            g.buffMonitor.KnownIDs[buffID] = true
+        state, known := g.buffMonitor.KnownIDs[buffID]
+        if !known {
+            g.buffMonitor.KnownIDs[buffID] = monitor.NewEntryState(info.TimeLeft, info.Duration, info.Stack)
 
-            reacted, reactedName := g.buffMonitor.Whitelist.ReactInstant(buffID)
+            ctx.Trigger = monitor.TriggerAppeared
+            reacted, rule, action := g.buffMonitor.React(ctx)
 
             if reacted {
-                fmt.Printf("[BUFF] %s (ID:%d) -> REACT!\n", reactedName, buffID)
+                fmt.Printf("[BUFF] %s (ID:%d) -> REACT %s\n", rule.Name, buffID, action.Key)
             }
 
-            g.buffMonitor.AddEvent("+", buffID, buffName, reacted)
+            g.buffMonitor.AddEvent("+", buffID, stackLabel(buffName, info.Stack), reacted)
+        } else {
+            g.reactBuffChange(state, ctx, info.Duration, buffName)
         }
 
-        newBuffs = append(newBuffs, monitor.BuffInfo{
-            Index:    i,
-            ID:       buffID,
-            Duration: duration,
-            TimeLeft: timeLeft,
-            Name:     buffName,
-        })
+        info.Name = buffName
+        newBuffs = append(newBuffs, info)
     }
 
+    now := time.Now()
     for id := range g.buffMonitor.KnownIDs {
         if !currentIDs[id] {
-            delete(g.buffMonitor.KnownIDs, id)
-            name := g.buffMonitor.Whitelist.GetName(id)
+            g.buffMonitor.Remove(id, now)
+            name := g.buffMonitor.Rules.Name(monitor.SourceBuff, id)
             g.buffMonitor.AddEvent("-", id, name, false)
         }
     }
@@ -487,90 +956,99 @@ func (g *Game) updateDebuffsInstant() {
         return
     }
 
+    p := g.profile.Debuff
     g.debuffMonitor.DebuffBase = debuffBase
-    count := memory.ReadU32(g.handle, debuffBase+config.OFF_DEBUFF_COUNT)
+    var countBuf [4]byte
+    countErr := memory.ReadMemoryBytes(g.mem, debuffBase+uintptr(p.Count), countBuf[:])
+    count := memory.BytesToUint32(countBuf[:])
     g.debuffMonitor.RawCount = count
 
-    if count == 0 || count > 50 {
-        if len(g.debuffMonitor.KnownIDs) > 0 {
-            for k := range g.debuffMonitor.KnownIDs {
-                delete(g.debuffMonitor.KnownIDs, k)
-            }
+    // Leitura falha ou lixo não é prova de que as entradas sumiram: só uma
+    // lista lida de verdade confirma as reações pendentes
+    if countErr != nil || count > 50 {
+        g.debuffMonitor.Reset()
+        g.debuffMonitor.Debuffs = g.debuffMonitor.Debuffs[:0]
+        return
+    }
+    if count == 0 {
+        now := time.Now()
+        for k := range g.debuffMonitor.KnownIDs {
+            g.debuffMonitor.Remove(k, now)
         }
         g.debuffMonitor.Debuffs = g.debuffMonitor.Debuffs[:0]
         return
     }
 
-    arrayAddr := debuffBase + config.OFF_DEBUFF_ARRAY
+    arrayAddr := debuffBase + uintptr(p.Array)
 
-    totalSize := int(count) * config.DEBUFF_SIZE
+    totalSize := int(count) * int(p.Size)
     if totalSize > len(debuffBuffer) {
         totalSize = len(debuffBuffer)
     }
 
-    var bytesRead uintptr
-    ret, _, _ := memory.ProcReadProcessMemory.Call(
-        uintptr(g.handle),
-        uintptr(arrayAddr),
-        uintptr(unsafe.Pointer(&debuffBuffer[0])),
-        uintptr(totalSize),
-        uintptr(unsafe.Pointer(&bytesRead)),
-    )
-
-    if ret == 0 {
+    bytesRead, err := g.mem.ReadBytes(arrayAddr, debuffBuffer[:totalSize])
+    if err != nil {
         return
     }
 
     newDebuffs := g.debuffMonitor.Debuffs[:0]
     currentIDs := make(map[uint64]bool, count)
 
-    maxItems := int(bytesRead) / config.DEBUFF_SIZE
+    maxItems := bytesRead / int(p.Size)
     if maxItems > 30 {
         maxItems = 30
     }
 
     for i := 0; i < maxItems; i++ {
-        offset := i * config.DEBUFF_SIZE
+        offset := i * int(p.Size)
 
-        id := memory.BytesToUint32(debuffBuffer[offset : offset+4])
-        typeID := memory.BytesToUint32(debuffBuffer[offset+4 : offset+8])
-        durMax := memory.BytesToUint32(debuffBuffer[offset+0x30 : offset+0x34])
-        durLeft := memory.BytesToUint32(debuffBuffer[offset+0x34 : offset+0x38])
+        info := monitor.DebuffInfo{Index: i}
+        entry := debuffBuffer[offset : offset+int(p.Size)]
+        if memory.DecodeBytes(g.mem, entry, arrayAddr+uintptr(offset), &info, g.profile) != nil {
+            return
+        }
 
+        id, typeID, durMax := info.ID, info.TypeID, info.DurMax
         if id < 1 || id > 50000 || durMax < 1000 || durMax > 300000 {
             continue
         }
 
+        info.CCName = g.debuffMonitor.Rules.Name(monitor.SourceDebuff, typeID)
+        newDebuffs = append(newDebuffs, info)
+    }
+
+    // Reações depois de ler a lista toda, para min_ccs contar todos os CCs
+    player := g.playerState()
+    ccs := g.debuffMonitor.CCCount(newDebuffs)
+
+    for _, info := range newDebuffs {
+        id, typeID := info.ID, info.TypeID
         key := monitor.MakeKey(id, typeID)
         currentIDs[key] = true
 
-        ccName := g.debuffMonitor.CCWhitelist.GetName(typeID)
+        ctx := monitor.Context{ID: typeID, Key: key, TimeLeft: info.DurLeft, Stack: info.Stack, CCs: ccs, Player: player}
 
-        if !g.debuffMonitor.KnownIDs[key] {
-            g.debuffMonitor.KnownIDs[key] = true
+        state, known := g.debuffMonitor.KnownIDs[key]
+        if !known {
+            g.debuffMonitor.KnownIDs[key] = monitor.NewEntryState(info.DurLeft, info.DurMax, info.Stack)
 
-            reacted, reactedName := g.debuffMonitor.CCWhitelist.ReactInstant(typeID)
+            ctx.Trigger = monitor.TriggerAppeared
+            reacted, rule, action := g.debuffMonitor.React(ctx)
 
             if reacted {
-                fmt.Printf("[CC] %s (T:%d) -> SPAM!\n", reactedName, typeID)
+                fmt.Printf("[CC] %s (T:%d) -> SPAM %s\n", rule.Name, typeID, action.Key)
             }
 
-            g.debuffMonitor.AddEvent("+", id, typeID, ccName, reacted)
+            g.debuffMonitor.AddEvent("+", id, typeID, stackLabel(info.CCName, info.Stack), reacted)
+        } else {
+            g.reactDebuffChange(state, ctx, info.DurMax, id, info.CCName)
         }
-
-        newDebuffs = append(newDebuffs, monitor.DebuffInfo{
-            Index:   i,
-            ID:      id,
-            TypeID:  typeID,
-            DurMax:  durMax,
-            DurLeft: durLeft,
-            CCName:  ccName,
-        })
     }
 
+    now := time.Now()
     for key := range g.debuffMonitor.KnownIDs {
         if !currentIDs[key] {
-            delete(g.debuffMonitor.KnownIDs, key)
+            g.debuffMonitor.Remove(key, now)
             id := uint32(key >> 32)
             typeID := uint32(key & 0xFFFFFFFF)
             g.debuffMonitor.AddEvent("-", id, typeID, "", false)
@@ -594,8 +1072,37 @@ func (g *Game) handleInput() {
     g.nuiNova.ToggleBtn.Hovered = g.nuiNova.ToggleBtn.Contains(g.mouseX, g.mouseY)
     g.mossyPool.ToggleBtn.Hovered = g.mossyPool.ToggleBtn.Contains(g.mouseX, g.mouseY)
     g.krakenMight.ToggleBtn.Hovered = g.krakenMight.ToggleBtn.Contains(g.mouseX, g.mouseY)
+    g.friendlyBtn.Hovered = g.friendlyBtn.Contains(g.mouseX, g.mouseY)
+    g.rotateBtn.Hovered = g.rotateBtn.Contains(g.mouseX, g.mouseY)
+    g.statsBtn.Hovered = g.statsBtn.Contains(g.mouseX, g.mouseY)
+    g.reportBtn.Hovered = g.reportBtn.Contains(g.mouseX, g.mouseY)
+
+    g.handleRadarInput()
 
     if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+        // Esconder amigos (friend/party/guild) do radar e da lista
+        if g.friendlyBtn.Contains(g.mouseX, g.mouseY) {
+            g.toggleHideFriendly()
+        }
+        if g.rotateBtn.Contains(g.mouseX, g.mouseY) {
+            g.toggleRadarRotate()
+        }
+        if g.statsBtn.Contains(g.mouseX, g.mouseY) {
+            g.toggleStats()
+        }
+        if g.reportBtn.Contains(g.mouseX, g.mouseY) {
+            g.exportReport()
+        }
+
+        // Clique numa entidade troca a relação
+        mx, my := float32(g.mouseX), float32(g.mouseY)
+        for _, r := range g.entityRows {
+            if mx >= r.x && mx <= r.x+r.w && my >= r.y && my <= r.y+r.h {
+                g.cycleRelation(r.name, r.relation)
+                break
+            }
+        }
+
         // Master toggle
         if g.masterToggleBtn.Contains(g.mouseX, g.mouseY) {
             g.autoPotEnabled = !g.autoPotEnabled
@@ -618,8 +1125,8 @@ func (g *Game) handleInput() {
 
         // CC Break
         if g.ccBreakBtn.Contains(g.mouseX, g.mouseY) {
-            g.debuffMonitor.CCWhitelist.Enabled = !g.debuffMonitor.CCWhitelist.Enabled
-            if g.debuffMonitor.CCWhitelist.Enabled {
+            g.debuffMonitor.BreakEnabled = !g.debuffMonitor.BreakEnabled
+            if g.debuffMonitor.BreakEnabled {
                 g.ccBreakBtn.Label = "CCBreak:ON"
             } else {
                 g.ccBreakBtn.Label = "CCBreak:OFF"
@@ -638,8 +1145,8 @@ func (g *Game) handleInput() {
 
         // Buff break
         if g.buffBreakBtn.Contains(g.mouseX, g.mouseY) {
-            g.buffMonitor.Whitelist.Enabled = !g.buffMonitor.Whitelist.Enabled
-            if g.buffMonitor.Whitelist.Enabled {
+            g.buffMonitor.BreakEnabled = !g.buffMonitor.BreakEnabled
+            if g.buffMonitor.BreakEnabled {
                 g.buffBreakBtn.Label = "BuffBrk:ON"
             } else {
                 g.buffBreakBtn.Label = "BuffBrk:OFF"
@@ -736,8 +1243,8 @@ func (g *Game) handleInput() {
 
     // Hotkeys
     if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
-        g.debuffMonitor.CCWhitelist.Enabled = !g.debuffMonitor.CCWhitelist.Enabled
-        if g.debuffMonitor.CCWhitelist.Enabled {
+        g.debuffMonitor.BreakEnabled = !g.debuffMonitor.BreakEnabled
+        if g.debuffMonitor.BreakEnabled {
             g.ccBreakBtn.Label = "CCBreak:ON"
         } else {
             g.ccBreakBtn.Label = "CCBreak:OFF"
@@ -745,8 +1252,8 @@ func (g *Game) handleInput() {
     }
 
     if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
-        g.buffMonitor.Whitelist.Enabled = !g.buffMonitor.Whitelist.Enabled
-        if g.buffMonitor.Whitelist.Enabled {
+        g.buffMonitor.BreakEnabled = !g.buffMonitor.BreakEnabled
+        if g.buffMonitor.BreakEnabled {
             g.buffBreakBtn.Label = "BuffBrk:ON"
         } else {
             g.buffBreakBtn.Label = "BuffBrk:OFF"
@@ -766,12 +1273,38 @@ func (g *Game) handleInput() {
             fmt.Println("[FREEZE] OFF")
         }
     }
+
+    // F6 - Esconder amigos
+    if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+        g.toggleHideFriendly()
+    }
+
+    // F7 - Radar girando com o player
+    if inpututil.IsKeyJustPressed(ebiten.KeyF7) {
+        g.toggleRadarRotate()
+    }
+
+    // F8 - Estatísticas das reações
+    if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+        g.toggleStats()
+    }
+
+    // F9 - Snapshot da memória
+    if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+        g.startSnapshotCapture()
+    }
 }
 
 func (g *Game) Update() error {
     g.handleInput()
 
-    if !g.connected {
+    if g.connected && !g.replay && time.Since(g.lastAttachCheck) >= attachCheckInterval {
+        g.checkAttached()
+    }
+    if g.connected && g.retryProfile && time.Since(g.lastProfileTry) >= profileRetryInterval {
+        g.selectProfile()
+    }
+    if !g.connected || g.profile == nil {
         return nil
     }
 
@@ -779,33 +1312,48 @@ func (g *Game) Update() error {
 
     g.updateDebuffsInstant()
     g.updateBuffsInstant()
+    g.debuffMonitor.Stats.Expire(time.Now())
 
     // Freeze buff value every frame if enabled
     g.freezeBuffValue()
 
+    playerMount := entity.GetPlayerMount(g.mem, g.x2game)
+    g.mutex.Lock()
+    g.playerMount = playerMount
+    g.mutex.Unlock()
+    g.mountConfig.Update(playerMount.Address, playerMount.Name, playerMount.HP, playerMount.MaxHP)
+
+    prev := g.localPlayer
+    g.localPlayer = entity.GetLocalPlayer(g.mem, g.x2game)
+    g.checkTeleport(prev, g.localPlayer)
+
     if g.frameCount%5 == 0 {
-        g.localPlayer = entity.GetLocalPlayer(g.handle, g.x2game)
         g.checkAndUsePotion()
-    }
 
-    if time.Since(g.lastEntityScan) >= g.entityScanInterval && !g.scanningEntities {
-        g.lastEntityScan = time.Now()
+        target, ok := entity.GetTarget(g.mem, g.x2game)
+        g.mutex.Lock()
+        g.target, g.hasTarget = target, ok
+        g.mutex.Unlock()
+    }
 
-        if g.localPlayer.Address != 0 {
-            g.scanningEntities = true
+    // Posição/HP das entidades conhecidas entre os scans completos
+    if g.frameCount%3 == 0 && g.localPlayer.Address != 0 {
+        g.tracker.Refresh(g.mem, g.localPlayer, time.Now())
+        for _, a := range g.watchlist.Update(g.tracker.Entities(), time.Now()) {
+            fmt.Printf("[WATCH] ★ %s a %.0fm %s\n", a.Name, a.Distance, a.Entry.Note)
+        }
+    }
+    g.handleEntityEvents(g.tracker.Events())
 
-            playerCopy := g.localPlayer
-            handleCopy := g.handle
+    g.mutex.RLock()
+    scanning := g.scanCancel != nil
+    g.mutex.RUnlock()
 
-            go func() {
-                entities := entity.FindAllEntities(handleCopy, playerCopy, config.SCAN_RANGE)
-                filtered := entity.FilterEntities(entities, playerCopy)
+    if time.Since(g.lastEntityScan) >= g.entityScanInterval && !scanning {
+        g.lastEntityScan = time.Now()
 
-                g.mutex.Lock()
-                g.entities = filtered
-                g.scanningEntities = false
-                g.mutex.Unlock()
-            }()
+        if g.localPlayer.Address != 0 {
+            g.startEntityScan()
         }
     }
 
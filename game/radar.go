@@ -0,0 +1,164 @@
+package game
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "muletinha/config"
+    "muletinha/entity"
+    "os"
+)
+
+// radarOptions são os extras do radar por tipo de entidade, em
+// radar_config.json.
+type radarOptions struct {
+    Trails          []entity.Kind `json:"trails"`           // tipos com rastro
+    Heights         []entity.Kind `json:"heights"`          // tipos com seta de altura
+    TrailSeconds    float32       `json:"trail_seconds"`    // idade máxima do rastro desenhado
+    HeightThreshold float32       `json:"height_threshold"` // metros acima/abaixo do player para a seta
+
+    trails, heights map[entity.Kind]bool
+}
+
+func newRadarOptions() *radarOptions {
+    o := &radarOptions{
+        Trails:          []entity.Kind{entity.KindPlayer, entity.KindMount},
+        Heights:         []entity.Kind{entity.KindPlayer, entity.KindNPC, entity.KindMount, entity.KindPet, entity.KindGatherable},
+        TrailSeconds:    15,
+        HeightThreshold: 8,
+    }
+    o.LoadFromFile("radar_config.json")
+    return o
+}
+
+func (o *radarOptions) LoadFromFile(filename string) {
+    data, err := os.ReadFile(filename)
+    if err != nil {
+        o.compile()
+        if data, err := json.MarshalIndent(o, "", "  "); err == nil {
+            os.WriteFile(filename, data, 0644)
+            fmt.Printf("[RADAR] Criado arquivo %s\n", filename)
+        }
+        return
+    }
+
+    if err := json.Unmarshal(data, o); err != nil {
+        fmt.Printf("[RADAR] Erro JSON: %v\n", err)
+    }
+    o.compile()
+
+    fmt.Printf("[RADAR] Rastro: %v  Altura: %v (%.0fm)\n", o.Trails, o.Heights, o.HeightThreshold)
+}
+
+func (o *radarOptions) compile() {
+    o.trails = make(map[entity.Kind]bool)
+    o.heights = make(map[entity.Kind]bool)
+    for _, k := range o.Trails {
+        o.trails[k] = true
+    }
+    for _, k := range o.Heights {
+        o.heights[k] = true
+    }
+}
+
+func (o *radarOptions) trail(k entity.Kind) bool  { return o.trails[k] }
+func (o *radarOptions) height(k entity.Kind) bool { return o.heights[k] }
+
+// radarView é o zoom, o deslocamento e a orientação do radar. O centro e o
+// raio na tela são gravados no Draw, como os botões, para o Update saber se
+// o mouse está sobre o radar.
+type radarView struct {
+    Range      float32 // metros do centro até a borda
+    PanX, PanY float32 // deslocamento do centro em relação ao player, em metros
+    Rotate     bool    // gira com o heading do player (perfil com entity.heading)
+
+    centerX, centerY, radius float32
+
+    dragging     bool
+    dragX, dragY int
+}
+
+const radarZoomStep = 1.25
+
+func newRadarView() radarView {
+    return radarView{Range: config.RADAR_RANGE}
+}
+
+func (v *radarView) contains(x, y int) bool {
+    dx, dy := float32(x)-v.centerX, float32(y)-v.centerY
+    return dx*dx+dy*dy <= v.radius*v.radius
+}
+
+func (v *radarView) scale() float32 {
+    return v.radius / v.Range
+}
+
+// zoom aproxima (steps > 0) ou afasta o radar, uma roda do mouse por passo.
+func (v *radarView) zoom(steps float64) {
+    r := float64(v.Range) / math.Pow(radarZoomStep, steps)
+    v.Range = float32(math.Max(config.RADAR_MIN_RANGE, math.Min(config.RADAR_MAX_RANGE, r)))
+}
+
+// project converte um deslocamento no mundo (em relação ao centro do radar)
+// para pixels em relação ao centro. Com heading h o mundo gira -h, deixando
+// a frente do player para cima.
+func (v *radarView) project(dx, dy, heading float32) (float32, float32) {
+    sin, cos := math.Sincos(float64(heading))
+    s := float64(v.scale())
+    rx := float64(dx)*cos - float64(dy)*sin
+    ry := float64(dx)*sin + float64(dy)*cos
+    return float32(rx * s), float32(-ry * s)
+}
+
+// unproject é o inverso de project, para arrastar.
+func (v *radarView) unproject(px, py, heading float32) (float32, float32) {
+    sin, cos := math.Sincos(float64(heading))
+    s := float64(v.scale())
+    rx, ry := float64(px)/s, -float64(py)/s
+    return float32(rx*cos + ry*sin), float32(-rx*sin + ry*cos)
+}
+
+// drag move o centro do radar junto com o mouse.
+func (v *radarView) drag(mouseX, mouseY int, heading float32) {
+    dx, dy := v.unproject(float32(mouseX-v.dragX), float32(mouseY-v.dragY), heading)
+    v.PanX -= dx
+    v.PanY -= dy
+    v.dragX, v.dragY = mouseX, mouseY
+
+    // O centro não se afasta mais do que o alcance máximo
+    if d := float32(math.Hypot(float64(v.PanX), float64(v.PanY))); d > config.RADAR_MAX_RANGE {
+        v.PanX *= config.RADAR_MAX_RANGE / d
+        v.PanY *= config.RADAR_MAX_RANGE / d
+    }
+}
+
+func (v *radarView) recenter() {
+    v.PanX, v.PanY = 0, 0
+}
+
+// scanRange is how far from the player the scanner must look so everything
+// the radar can show is found; never less than config.SCAN_RANGE.
+func (v *radarView) scanRange() float32 {
+    r := v.Range + float32(math.Hypot(float64(v.PanX), float64(v.PanY)))
+    if r < config.SCAN_RANGE {
+        return config.SCAN_RANGE
+    }
+    return r
+}
+
+// rangeRings returns up to five round distances (in meters) for the rings.
+func (v *radarView) rangeRings() []float32 {
+    step := float32(math.Pow(10, math.Floor(math.Log10(float64(v.Range)/2))))
+    for _, m := range []float32{1, 2, 5, 10} {
+        if v.Range/(step*m) <= 5 {
+            step *= m
+            break
+        }
+    }
+
+    var rings []float32
+    for r := step; r <= v.Range+0.5; r += step {
+        rings = append(rings, r)
+    }
+    return rings
+}
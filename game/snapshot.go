@@ -0,0 +1,134 @@
+package game
+
+import (
+    "fmt"
+    "math"
+    "muletinha/entity"
+    "muletinha/memory"
+    "muletinha/offsets"
+    "muletinha/sigscan"
+    "muletinha/snapshot"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// NewReplayGame roda o overlay contra um snapshot gravado com F9, sem o
+// cliente aberto. Reações e potions ficam desligadas para não enviar teclas.
+func NewReplayGame(filename string) *Game {
+    g := newGame()
+
+    snap, err := snapshot.Open(filename)
+    if err != nil {
+        fmt.Println("Erro ao abrir snapshot:", err)
+        return g
+    }
+
+    x2game, err := snap.ModuleBase("x2game.dll")
+    if err != nil {
+        fmt.Println("x2game.dll não encontrado no snapshot!")
+        return g
+    }
+    icudt42, _ := snap.ModuleBase("icudt42.dll")
+
+    g.mem = snap
+    g.x2game = x2game
+    g.icudt42 = icudt42
+    g.connected = true
+    g.replay = true
+    refreshAddressMap(snap)
+    g.build = offsets.DetectBuild(snap, x2game, "")
+    g.selectProfile()
+
+    g.autoPotEnabled = false
+    g.masterToggleBtn.Label = "AutoPot:OFF"
+    g.debuffMonitor.BreakEnabled = false
+    g.ccBreakBtn.Label = "CCBreak:OFF"
+    g.buffMonitor.BreakEnabled = false
+    g.buffBreakBtn.Label = "BuffBrk:OFF"
+    g.mountConfig.Enabled = false
+
+    fmt.Printf("[REPLAY] %s: %d blocos, %d KB, capturado em %s\n",
+        filename, len(snap.File.Blocks), snap.File.Size()/1024, snap.File.CapturedAt.Format("2006-01-02 15:04:05"))
+    fmt.Printf("[INFO] x2game.dll base: %08X\n", x2game)
+
+    return g
+}
+
+// startSnapshotCapture grava as regiões que o overlay lê: cadeia do
+// localplayer, mana, listas de buff/debuff, buff freeze, target, montaria e as
+// regiões varridas pelo scanner de entidades.
+func (g *Game) startSnapshotCapture() {
+    if !g.connected || g.replay || g.profile == nil {
+        return
+    }
+
+    // capturing volta a false na goroutine da captura, sob g.mutex
+    g.mutex.Lock()
+    busy := g.capturing
+    g.capturing = true
+    g.mutex.Unlock()
+    if busy {
+        return
+    }
+
+    rec := memory.NewRecorder(g.mem)
+
+    // A parte rápida roda aqui no Update, com g.mem trocado pelo gravador para
+    // reaproveitar os mesmos resolvers das cadeias.
+    live := g.mem
+    g.mem = rec
+    g.debuffList.Invalidate()
+    g.buffList.Invalidate()
+
+    // Header PE e seções de código: o replay identifica a build por eles e
+    // as assinaturas do perfil podem ser testadas offline no snapshot
+    offsets.ReadBuildID(rec, g.x2game)
+    sigscan.ReadModule(rec, g.x2game)
+
+    p := g.profile
+    player := entity.GetLocalPlayer(rec, g.x2game)
+    if base := g.getDebuffBaseFast(); base != 0 {
+        memory.ReadU32(rec, base+uintptr(p.Debuff.Count))
+        memory.ReadMemoryBytes(rec, base+uintptr(p.Debuff.Array), make([]byte, 30*p.Debuff.Size))
+    }
+    if list := g.findBuffListFromPlayer(); list != 0 {
+        memory.ReadU32(rec, list+uintptr(p.Buff.Count))
+        memory.ReadMemoryBytes(rec, list+uintptr(p.Buff.Array), make([]byte, 30*p.Buff.Size))
+    }
+    g.readBuffFreezeValue()
+    entity.GetTarget(rec, g.x2game)
+    entity.GetPlayerMount(rec, g.x2game)
+
+    g.mem = live
+    g.debuffList.Invalidate()
+    g.buffList.Invalidate()
+
+    modules := []snapshot.Module{{Name: "x2game.dll", Base: g.x2game}}
+    if g.icudt42 != 0 {
+        modules = append(modules, snapshot.Module{Name: "icudt42.dll", Base: g.icudt42})
+    }
+
+    fmt.Println("[SNAPSHOT] Capturando...")
+    go func() {
+        defer func() {
+            g.mutex.Lock()
+            g.capturing = false
+            g.mutex.Unlock()
+        }()
+
+        start := time.Now()
+        // Sem limite de distância: grava também as entidades fora do radar
+        entity.FindAllEntities(rec, player, math.MaxFloat32)
+
+        snap := snapshot.FromRecorder(rec, "archeage.exe", modules)
+        os.MkdirAll("snapshots", 0755)
+        filename := filepath.Join("snapshots", "snap_"+snap.CapturedAt.Format("20060102_150405")+".snap")
+        if err := snap.Save(filename); err != nil {
+            fmt.Printf("[SNAPSHOT] Erro ao salvar: %v\n", err)
+            return
+        }
+        fmt.Printf("[SNAPSHOT] %s salvo (%d KB em %d blocos, %v)\n",
+            filename, snap.Size()/1024, len(snap.Blocks), time.Since(start).Round(time.Millisecond))
+    }()
+}
@@ -1,3 +1,5 @@
+//go:build windows
+
 // interception.go
 package input
 
@@ -0,0 +1,116 @@
+//go:build linux
+
+package input
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// No Linux, o jogo roda sob Wine/Proton e recebe eventos do X11, então as
+// teclas são enviadas com xdotool para a janela em foco.
+
+var (
+	xdotoolPath string
+	inputMutex  sync.Mutex
+)
+
+// Nomes do keyCodeMap/modifierMap -> keysyms do X11
+var xKeysymMap = map[string]string{
+	"SHIFT": "shift", "LSHIFT": "Shift_L", "RSHIFT": "Shift_R",
+	"CTRL": "ctrl", "CONTROL": "ctrl", "LCTRL": "Control_L", "LCONTROL": "Control_L",
+	"RCTRL": "Control_R", "RCONTROL": "Control_R",
+	"ALT": "alt", "LALT": "Alt_L", "RALT": "Alt_R",
+	"SPACE": "space", "ENTER": "Return", "TAB": "Tab",
+	"ESC": "Escape", "ESCAPE": "Escape",
+	"BACKSPACE": "BackSpace", "DELETE": "Delete", "INSERT": "Insert",
+	"HOME": "Home", "END": "End", "PAGEUP": "Prior", "PAGEDOWN": "Next",
+	"UP": "Up", "DOWN": "Down", "LEFT": "Left", "RIGHT": "Right",
+	"`": "grave", "TILDE": "grave", "~": "grave",
+	"-": "minus", "=": "equal",
+	"[": "bracketleft", "]": "bracketright", "\\": "backslash",
+	";": "semicolon", "'": "apostrophe",
+	",": "comma", ".": "period", "/": "slash",
+}
+
+func xKeysym(name string) string {
+	if sym, ok := xKeysymMap[name]; ok {
+		return sym
+	}
+	if strings.HasPrefix(name, "NUMPAD") {
+		return "KP_" + strings.TrimPrefix(name, "NUMPAD")
+	}
+	if strings.HasPrefix(name, "NUM") {
+		return "KP_" + strings.TrimPrefix(name, "NUM")
+	}
+	if len(name) == 1 {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// xdotoolCombo converte "LSHIFT+F1" em "Shift_L+F1"
+func xdotoolCombo(keyStr string) string {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(keyStr)), "+")
+	syms := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		syms = append(syms, xKeysym(part))
+	}
+	return strings.Join(syms, "+")
+}
+
+// InitVirtualKeyboard localiza o xdotool
+func InitVirtualKeyboard() error {
+	path, err := exec.LookPath("xdotool")
+	if err != nil {
+		return fmt.Errorf("xdotool not found in PATH: %v", err)
+	}
+	xdotoolPath = path
+	return nil
+}
+
+func CloseVirtualKeyboard() {}
+
+func sendXdotool(keyStr string) {
+	if xdotoolPath == "" {
+		return
+	}
+	combo := xdotoolCombo(keyStr)
+	if combo == "" {
+		return
+	}
+	if err := exec.Command(xdotoolPath, "key", "--clearmodifiers", combo).Run(); err != nil {
+		fmt.Printf("[KEY] xdotool falhou (%s): %v\n", combo, err)
+	}
+}
+
+func SendKey(keyStr string) {
+	inputMutex.Lock()
+	defer inputMutex.Unlock()
+	sendXdotool(keyStr)
+}
+
+func SendKeyCombo(combo KeyCombo) {
+	SendKey(combo.RawString)
+}
+
+func SpamKeyCombo(combo KeyCombo, count int, interval time.Duration) {
+	SpamKey(combo.RawString, count, interval)
+}
+
+// SpamKey spam de teclas via xdotool
+func SpamKey(keyStr string, count int, interval time.Duration) {
+	for i := 0; i < count; i++ {
+		SendKey(keyStr)
+		if i < count-1 && interval > 0 {
+			time.Sleep(interval)
+		}
+	}
+}
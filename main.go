@@ -5,15 +5,25 @@ import (
 	"muletinha/config"
 	"muletinha/game"
 	"muletinha/input"
+	"os"
 	"runtime"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"golang.org/x/sys/windows"
 )
 
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	if len(os.Args) > 1 && os.Args[1] == "sigscan" {
+		os.Exit(runSigscan(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diagnose" {
+		os.Exit(runDiagnose(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
+
 	if err := input.InitVirtualKeyboard(); err != nil {
 		fmt.Printf("[Input] Interception não disponível: %v\n", err)
 		fmt.Println("[Input] Usando SendInput como fallback (pode interferir com teclado do usuário)")
@@ -28,13 +38,17 @@ func main() {
 	ebiten.SetTPS(60)
 	ebiten.SetVsyncEnabled(true)
 
-	g := game.NewGame()
+	var g *game.Game
+	if len(os.Args) > 2 && os.Args[1] == "replay" {
+		ebiten.SetWindowTitle("Muletinha GOTY Edition - Replay")
+		g = game.NewReplayGame(os.Args[2])
+	} else {
+		g = game.NewGame()
+	}
 
 	if err := ebiten.RunGame(g); err != nil {
 		fmt.Println("Erro:", err)
 	}
 
-	if handle := g.GetHandle(); handle != 0 {
-		windows.CloseHandle(handle)
-	}
+	g.Close()
 }
\ No newline at end of file
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PtrCheck selects how each pointer read along a chain is validated.
+type PtrCheck int
+
+const (
+	CheckNonNull  PtrCheck = iota // only rejects 0
+	CheckValidPtr                 // must also pass IsValidPtr
+)
+
+var (
+	ErrNullPointer  = errors.New("null pointer")
+	ErrInvalidRange = errors.New("pointer out of valid range")
+	ErrReadFailed   = errors.New("read failed")
+)
+
+// PointerChain describes a multi-level pointer walk as data:
+//
+//	[[[base+Base]+Offsets[0]]+Offsets[1]...]+Final
+//
+// Module names the module whose base the chain starts from; an empty Module
+// means the chain is relative to an object address (e.g. an entity).
+type PointerChain struct {
+	Name    string
+	Module  string
+	Base    uintptr
+	Offsets []uint32
+	Final   uint32
+	Check   PtrCheck
+}
+
+// ChainError reports which hop of a chain failed and why.
+type ChainError struct {
+	Chain string
+	Hop   int     // 0 is the read at base+Base
+	Addr  uintptr // address that was read
+	Value uint32  // value read, when the read succeeded
+	Err   error   // ErrNullPointer, ErrInvalidRange or ErrReadFailed
+}
+
+func (e *ChainError) Error() string {
+	if e.Err == ErrInvalidRange {
+		return fmt.Sprintf("%s: hop %d @ %08X: %v (%08X)", e.Chain, e.Hop, e.Addr, e.Err, e.Value)
+	}
+	return fmt.Sprintf("%s: hop %d @ %08X: %v", e.Chain, e.Hop, e.Addr, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// Hops returns the number of pointer reads the chain performs.
+func (c *PointerChain) Hops() int {
+	return len(c.Offsets) + 1
+}
+
+// Resolve walks the chain from base (the module base, or the object address
+// for relative chains) and returns the final address.
+func (c *PointerChain) Resolve(pm ProcessMemory, base uintptr) (uintptr, error) {
+	addr := base + c.Base
+	var b [4]byte
+
+	for hop := 0; hop <= len(c.Offsets); hop++ {
+		if err := ReadMemoryBytes(pm, addr, b[:]); err != nil {
+			return 0, &ChainError{Chain: c.Name, Hop: hop, Addr: addr, Err: ErrReadFailed}
+		}
+
+		ptr := binary.LittleEndian.Uint32(b[:])
+		if ptr == 0 {
+			return 0, &ChainError{Chain: c.Name, Hop: hop, Addr: addr, Err: ErrNullPointer}
+		}
+		if c.Check == CheckValidPtr && !IsValidPtr(ptr) {
+			return 0, &ChainError{Chain: c.Name, Hop: hop, Addr: addr, Value: ptr, Err: ErrInvalidRange}
+		}
+
+		if hop == len(c.Offsets) {
+			return uintptr(ptr) + uintptr(c.Final), nil
+		}
+		addr = uintptr(ptr) + uintptr(c.Offsets[hop])
+	}
+	return 0, nil
+}
+
+// CachedChain keeps the last resolved address of a chain for TTL.
+type CachedChain struct {
+	Chain   PointerChain
+	TTL     time.Duration
+	LastErr error
+
+	addr uintptr
+	at   time.Time
+}
+
+func NewCachedChain(chain PointerChain, ttl time.Duration) *CachedChain {
+	return &CachedChain{Chain: chain, TTL: ttl}
+}
+
+func (c *CachedChain) Resolve(pm ProcessMemory, base uintptr) (uintptr, error) {
+	if c.addr != 0 && time.Since(c.at) < c.TTL {
+		return c.addr, nil
+	}
+
+	addr, err := c.Chain.Resolve(pm, base)
+	c.LastErr = err
+	if err != nil {
+		return 0, err
+	}
+
+	c.addr = addr
+	c.at = time.Now()
+	return addr, nil
+}
+
+// Invalidate forces the next Resolve to walk the chain again.
+func (c *CachedChain) Invalidate() {
+	c.addr = 0
+}
+
+// JSON form used by the offset profiles: addresses as hex strings.
+//
+//	{"module": "x2game.dll", "base": "0xE9DC54", "offsets": ["0x10"], "final": "0x0", "check": "valid"}
+type chainJSON struct {
+	Module  string   `json:"module,omitempty"`
+	Base    string   `json:"base"`
+	Offsets []string `json:"offsets"`
+	Final   string   `json:"final,omitempty"`
+	Check   string   `json:"check,omitempty"`
+}
+
+func (c PointerChain) MarshalJSON() ([]byte, error) {
+	j := chainJSON{
+		Module:  c.Module,
+		Base:    fmt.Sprintf("0x%X", c.Base),
+		Offsets: make([]string, len(c.Offsets)),
+	}
+	for i, off := range c.Offsets {
+		j.Offsets[i] = fmt.Sprintf("0x%X", off)
+	}
+	if c.Final != 0 {
+		j.Final = fmt.Sprintf("0x%X", c.Final)
+	}
+	if c.Check == CheckValidPtr {
+		j.Check = "valid"
+	}
+	return json.Marshal(j)
+}
+
+func (c *PointerChain) UnmarshalJSON(data []byte) error {
+	var j chainJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	base, err := ParseHex(j.Base)
+	if err != nil {
+		return fmt.Errorf("base: %v", err)
+	}
+
+	chain := PointerChain{Name: c.Name, Module: j.Module, Base: uintptr(base)}
+	for i, s := range j.Offsets {
+		off, err := ParseHex(s)
+		if err != nil {
+			return fmt.Errorf("offsets[%d]: %v", i, err)
+		}
+		chain.Offsets = append(chain.Offsets, uint32(off))
+	}
+	if j.Final != "" {
+		final, err := ParseHex(j.Final)
+		if err != nil {
+			return fmt.Errorf("final: %v", err)
+		}
+		chain.Final = uint32(final)
+	}
+
+	switch j.Check {
+	case "", "nonnull":
+		chain.Check = CheckNonNull
+	case "valid":
+		chain.Check = CheckValidPtr
+	default:
+		return fmt.Errorf("check: unknown %q", j.Check)
+	}
+
+	*c = chain
+	return nil
+}
+
+// ParseHex parses "0x84C" (hex) or "2124" (decimal).
+func ParseHex(s string) (uint32, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 0, 32)
+	return uint32(v), err
+}
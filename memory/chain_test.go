@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+// chainImage maps module+0x100 -> 0x20000000, [0x20000000+0x10] -> 0x20001000.
+func chainImage(hop1 uint32) *Image {
+	img := NewImage()
+	img.Map(0x400100, u32(0x20000000))
+	obj := make([]byte, 0x20)
+	binary.LittleEndian.PutUint32(obj[0x10:], hop1)
+	img.Map(0x20000000, obj)
+	return img
+}
+
+func TestPointerChainResolve(t *testing.T) {
+	chain := PointerChain{Name: "test", Base: 0x100, Offsets: []uint32{0x10}, Final: 0x8}
+
+	tests := []struct {
+		name    string
+		img     *Image
+		chain   PointerChain
+		want    uintptr
+		hop     int
+		wantErr error
+	}{
+		{name: "ok", img: chainImage(0x20001000), chain: chain, want: 0x20001008},
+		{name: "null at hop 1", img: chainImage(0), chain: chain, hop: 1, wantErr: ErrNullPointer},
+		{name: "unmapped base", img: NewImage(), chain: chain, hop: 0, wantErr: ErrReadFailed},
+		{
+			name:  "out of range with CheckValidPtr",
+			img:   chainImage(0x1234),
+			chain: PointerChain{Name: "test", Base: 0x100, Offsets: []uint32{0x10}, Check: CheckValidPtr},
+			hop:   1, wantErr: ErrInvalidRange,
+		},
+		{
+			name:  "no check accepts any non-null",
+			img:   chainImage(0x1234),
+			chain: PointerChain{Name: "test", Base: 0x100, Offsets: []uint32{0x10}},
+			want:  0x1234,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.chain.Resolve(tt.img, 0x400000)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Resolve: %v", err)
+				}
+				if got != tt.want {
+					t.Fatalf("Resolve = %08X, want %08X", got, tt.want)
+				}
+				return
+			}
+
+			var ce *ChainError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Resolve error = %v, want *ChainError", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", ce.Err, tt.wantErr)
+			}
+			if ce.Hop != tt.hop {
+				t.Errorf("hop = %d, want %d", ce.Hop, tt.hop)
+			}
+			if ce.Chain != "test" {
+				t.Errorf("chain = %q, want %q", ce.Chain, "test")
+			}
+		})
+	}
+}
+
+func TestCachedChainInvalidate(t *testing.T) {
+	img := chainImage(0x20001000)
+	c := NewCachedChain(PointerChain{Base: 0x100, Offsets: []uint32{0x10}}, time.Hour)
+
+	if addr, err := c.Resolve(img, 0x400000); err != nil || addr != 0x20001000 {
+		t.Fatalf("Resolve = %08X, %v", addr, err)
+	}
+
+	// O cache segura o endereço antigo até Invalidate
+	img.WriteBytes(0x20000010, u32(0x20002000))
+	if addr, _ := c.Resolve(img, 0x400000); addr != 0x20001000 {
+		t.Fatalf("cached Resolve = %08X, want 20001000", addr)
+	}
+	c.Invalidate()
+	if addr, _ := c.Resolve(img, 0x400000); addr != 0x20002000 {
+		t.Fatalf("Resolve after Invalidate = %08X, want 20002000", addr)
+	}
+}
@@ -0,0 +1,340 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Structs describe their memory layout with `mem` tags and are filled from
+// one bulk read of the span they cover:
+//
+//	type Entity struct {
+//		VTable uint32  `mem:"0x0"`
+//		HP     uint32  `mem:"entity.hp"`           // offset from the profile
+//		Name   string  `mem:"@entity_name,str=32"` // chain from the profile
+//		Owner  *Other  `mem:"0x40,ptr"`            // pointer, decoded where it points
+//		Tag    string  `mem:"0x60,str=16"`         // fixed-size inline string
+//	}
+//
+// The location is a number, a symbolic offset or "@chain" (a relative
+// PointerChain walked from the struct address). Options: "ptr" (the field
+// holds a pointer to the value), "str=N" (string length in characters),
+// "utf8"/"utf16" (string encoding, otherwise the one the Symbols choose) and
+// "opt" (skip the field when the profile doesn't define the symbol or leaves
+// it at 0).
+// Fields without a tag are left alone. Nested structs without ptr are
+// decoded inline.
+
+// Symbols resolves the symbolic names used in mem tags.
+type Symbols interface {
+	Offset(name string) (uint32, bool)
+	Chain(name string) (*PointerChain, bool)
+}
+
+const defaultStrLen = 32
+
+type fieldLayout struct {
+	index  int
+	off    uint32
+	size   uint32 // bytes of the value itself
+	ptr    bool
+	chain  *PointerChain
+	strLen int
+	enc    Encoding
+	sub    *structLayout
+
+	optional bool // "opt": symbol ausente ou 0 no perfil, campo ignorado
+	used     bool
+}
+
+type structLayout struct {
+	fields []fieldLayout
+	span   uint32 // bytes needed from the struct start
+}
+
+type layoutKey struct {
+	t    reflect.Type
+	syms Symbols
+}
+
+var layouts sync.Map // layoutKey -> *structLayout
+
+func layoutOf(t reflect.Type, syms Symbols) (*structLayout, error) {
+	key := layoutKey{t, syms}
+	if l, ok := layouts.Load(key); ok {
+		return l.(*structLayout), nil
+	}
+
+	l, err := compileLayout(t, syms)
+	if err != nil {
+		return nil, err
+	}
+	layouts.Store(key, l)
+	return l, nil
+}
+
+func compileLayout(t reflect.Type, syms Symbols) (*structLayout, error) {
+	l := &structLayout{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("mem")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		f, err := compileField(sf, tag, syms)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", t.Name(), sf.Name, err)
+		}
+		if !f.used {
+			continue
+		}
+		f.index = i
+
+		end := f.off + f.size
+		if f.ptr {
+			end = f.off + 4
+		}
+		if f.chain == nil && end > l.span {
+			l.span = end
+		}
+		l.fields = append(l.fields, f)
+	}
+	return l, nil
+}
+
+func compileField(sf reflect.StructField, tag string, syms Symbols) (fieldLayout, error) {
+	var f fieldLayout
+	parts := strings.Split(tag, ",")
+
+	f.enc = UTF8
+	if se, ok := syms.(StringEncoder); ok && se.StringEncoding() != "" {
+		f.enc = se.StringEncoding()
+	}
+
+	for _, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "ptr":
+			f.ptr = true
+		case opt == "opt":
+			f.optional = true
+		case opt == "utf8":
+			f.enc = UTF8
+		case opt == "utf16":
+			f.enc = UTF16LE
+		case strings.HasPrefix(opt, "str="):
+			n, err := strconv.Atoi(opt[4:])
+			if err != nil || n <= 0 {
+				return f, fmt.Errorf("bad %s", opt)
+			}
+			f.strLen = n
+		default:
+			return f, fmt.Errorf("unknown option %s", opt)
+		}
+	}
+
+	loc := strings.TrimSpace(parts[0])
+	switch {
+	case strings.HasPrefix(loc, "@"):
+		if syms == nil {
+			return f, fmt.Errorf("chain %s without symbols", loc)
+		}
+		c, ok := syms.Chain(loc[1:])
+		if f.optional && (!ok || c.Base == 0) {
+			return f, nil
+		}
+		if !ok {
+			return f, fmt.Errorf("unknown chain %s", loc)
+		}
+		f.chain = c
+	case loc != "" && loc[0] >= '0' && loc[0] <= '9':
+		off, err := ParseHex(loc)
+		if err != nil {
+			return f, err
+		}
+		f.off = off
+	default:
+		if syms == nil {
+			return f, fmt.Errorf("symbol %s without symbols", loc)
+		}
+		off, ok := syms.Offset(loc)
+		if f.optional && (!ok || off == 0) {
+			return f, nil
+		}
+		if !ok {
+			return f, fmt.Errorf("unknown symbol %s", loc)
+		}
+		f.off = off
+	}
+
+	t := sf.Type
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		if !f.ptr && f.chain == nil {
+			return f, fmt.Errorf("pointer field needs ptr or a chain")
+		}
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if f.strLen == 0 {
+			if !f.ptr && f.chain == nil {
+				return f, fmt.Errorf("inline string needs str=N")
+			}
+			f.strLen = defaultStrLen
+		}
+		f.size = uint32(f.strLen * f.enc.UnitSize())
+	case reflect.Struct:
+		sub, err := layoutOf(t, syms)
+		if err != nil {
+			return f, err
+		}
+		f.sub = sub
+		f.size = sub.span
+	case reflect.Bool, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		f.size = uint32(t.Size())
+	default:
+		return f, fmt.Errorf("unsupported type %s", sf.Type)
+	}
+	f.used = true
+	return f, nil
+}
+
+// Span returns how many bytes from the start of v's struct a bulk read must
+// cover to decode its inline fields.
+func Span(v any, syms Symbols) (uint32, error) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	l, err := layoutOf(t, syms)
+	if err != nil {
+		return 0, err
+	}
+	return l.span, nil
+}
+
+// Decode fills the struct pointed to by v from addr with a single read of
+// its span, plus one read per pointer/chain field.
+func Decode(pm ProcessMemory, addr uintptr, v any, syms Symbols) error {
+	rv, l, err := decodeTarget(v, syms)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, l.span)
+	if err := ReadMemoryBytes(pm, addr, buf); err != nil {
+		return err
+	}
+	decodeStruct(pm, buf, addr, rv, l)
+	return nil
+}
+
+// DecodeBytes fills v from buf, which holds the memory at addr (e.g. one
+// entry of an array that was read in bulk). pm is only used for pointer and
+// chain fields.
+func DecodeBytes(pm ProcessMemory, buf []byte, addr uintptr, v any, syms Symbols) error {
+	rv, l, err := decodeTarget(v, syms)
+	if err != nil {
+		return err
+	}
+	if uint32(len(buf)) < l.span {
+		return fmt.Errorf("buffer too small at %08X: %d/%d", addr, len(buf), l.span)
+	}
+	decodeStruct(pm, buf, addr, rv, l)
+	return nil
+}
+
+func decodeTarget(v any, syms Symbols) (reflect.Value, *structLayout, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return rv, nil, fmt.Errorf("decode: need pointer to struct, got %T", v)
+	}
+	rv = rv.Elem()
+	l, err := layoutOf(rv.Type(), syms)
+	return rv, l, err
+}
+
+func decodeStruct(pm ProcessMemory, buf []byte, addr uintptr, rv reflect.Value, l *structLayout) {
+	for i := range l.fields {
+		f := &l.fields[i]
+		fv := rv.Field(f.index)
+
+		switch {
+		case f.chain != nil:
+			// Campo atrás de uma cadeia: falha deixa o valor zerado
+			if pm == nil {
+				continue
+			}
+			target, err := f.chain.Resolve(pm, addr)
+			if err != nil {
+				continue
+			}
+			decodeAt(pm, target, fv, f)
+		case f.ptr:
+			target := binary.LittleEndian.Uint32(buf[f.off:])
+			if target == 0 || pm == nil {
+				continue
+			}
+			decodeAt(pm, uintptr(target), fv, f)
+		default:
+			decodeValue(pm, buf[f.off:f.off+f.size], addr+uintptr(f.off), fv, f)
+		}
+	}
+}
+
+// decodeAt reads a value that lives outside the struct's bulk buffer.
+func decodeAt(pm ProcessMemory, addr uintptr, fv reflect.Value, f *fieldLayout) {
+	buf := make([]byte, f.size)
+	if err := ReadMemoryBytes(pm, addr, buf); err != nil {
+		return
+	}
+	decodeValue(pm, buf, addr, fv, f)
+}
+
+func decodeValue(pm ProcessMemory, b []byte, addr uintptr, fv reflect.Value, f *fieldLayout) {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		decodeStruct(pm, b, addr, fv, f.sub)
+	case reflect.String:
+		fv.SetString(DecodeString(b, f.enc))
+	case reflect.Bool:
+		fv.SetBool(b[0] != 0)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(readUint(b, f.size))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := readUint(b, f.size)
+		shift := 64 - 8*f.size
+		fv.SetInt(int64(v<<shift) >> shift)
+	case reflect.Float32:
+		fv.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+	case reflect.Float64:
+		fv.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	}
+}
+
+func readUint(b []byte, size uint32) uint64 {
+	switch size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
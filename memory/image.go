@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Image is an in-process memory backend: a sparse set of byte ranges mapped
+// at fixed addresses. It lets entity and monitor parsing run without the
+// game, e.g. in unit tests or when replaying recorded memory.
+type Image struct {
+	regions []imageRegion
+}
+
+type imageRegion struct {
+	base uintptr
+	data []byte
+}
+
+func NewImage() *Image {
+	return &Image{}
+}
+
+// Map places data at base. Overlapping an existing range is not supported.
+func (m *Image) Map(base uintptr, data []byte) {
+	i := sort.Search(len(m.regions), func(i int) bool { return m.regions[i].base > base })
+	m.regions = append(m.regions, imageRegion{})
+	copy(m.regions[i+1:], m.regions[i:])
+	m.regions[i] = imageRegion{base: base, data: data}
+}
+
+func (m *Image) find(addr uintptr) int {
+	i := sort.Search(len(m.regions), func(i int) bool {
+		r := m.regions[i]
+		return r.base+uintptr(len(r.data)) > addr
+	})
+	if i < len(m.regions) && m.regions[i].base <= addr {
+		return i
+	}
+	return -1
+}
+
+func (m *Image) ReadBytes(addr uintptr, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		i := m.find(addr + uintptr(n))
+		if i < 0 {
+			break
+		}
+		r := m.regions[i]
+		n += copy(buf[n:], r.data[addr+uintptr(n)-r.base:])
+	}
+	if n == 0 && len(buf) > 0 {
+		return 0, fmt.Errorf("unmapped address %08X", addr)
+	}
+	return n, nil
+}
+
+func (m *Image) WriteBytes(addr uintptr, data []byte) error {
+	i := m.find(addr)
+	if i < 0 {
+		return fmt.Errorf("unmapped address %08X", addr)
+	}
+	r := m.regions[i]
+	off := addr - r.base
+	if off+uintptr(len(data)) > uintptr(len(r.data)) {
+		return fmt.Errorf("write crosses region end at %08X", addr)
+	}
+	copy(r.data[off:], data)
+	return nil
+}
+
+func (m *Image) QueryRegion(addr uintptr) (Region, error) {
+	if i := m.find(addr); i >= 0 {
+		r := m.regions[i]
+		return Region{
+			Base:      r.base,
+			Size:      uintptr(len(r.data)),
+			Committed: true,
+			Readable:  true,
+			Writable:  true,
+			Private:   true,
+		}, nil
+	}
+
+	// Free gap up to the next mapped range, like VirtualQueryEx reports it.
+	next := ^uintptr(0)
+	i := sort.Search(len(m.regions), func(i int) bool { return m.regions[i].base > addr })
+	if i < len(m.regions) {
+		next = m.regions[i].base
+	}
+	return Region{Base: addr, Size: next - addr}, nil
+}
+
+// Regions lists the mapped ranges; everything between them is free.
+func (m *Image) Regions() ([]Region, error) {
+	regions := make([]Region, 0, len(m.regions))
+	for _, r := range m.regions {
+		regions = append(regions, Region{
+			Base:      r.base,
+			Size:      uintptr(len(r.data)),
+			Committed: true,
+			Readable:  true,
+			Writable:  true,
+			Private:   true,
+		})
+	}
+	return regions, nil
+}
+
+func (m *Image) Close() error {
+	return nil
+}
@@ -0,0 +1,165 @@
+//go:build linux
+
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// LinuxProcess reads a native or Wine/Proton process through
+// process_vm_readv, falling back to /proc/<pid>/mem when the syscall is
+// refused (e.g. kernels without CONFIG_CROSS_MEMORY_ATTACH). It works on any
+// pid the caller may ptrace, including its own.
+type LinuxProcess struct {
+	Pid     int
+	memFile *os.File
+	vmRead  bool
+}
+
+// OpenProcess attaches to pid through procfs.
+func OpenProcess(pid uint32) (ProcessMemory, error) {
+	path := fmt.Sprintf("/proc/%d/mem", pid)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		f, err = os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &LinuxProcess{Pid: int(pid), memFile: f, vmRead: true}, nil
+}
+
+func (p *LinuxProcess) ReadBytes(addr uintptr, buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	if p.vmRead {
+		local := []unix.Iovec{{Base: &buf[0]}}
+		local[0].SetLen(len(buf))
+		remote := []unix.RemoteIovec{{Base: addr, Len: len(buf)}}
+
+		n, err := unix.ProcessVMReadv(p.Pid, local, remote, 0)
+		if err == nil {
+			return n, nil
+		}
+		if err != unix.ENOSYS && err != unix.EPERM {
+			return 0, fmt.Errorf("read failed at %08X: %v", addr, err)
+		}
+		p.vmRead = false
+	}
+
+	n, err := p.memFile.ReadAt(buf, int64(addr))
+	if n == 0 && err != nil {
+		return 0, fmt.Errorf("read failed at %08X: %v", addr, err)
+	}
+	return n, nil
+}
+
+func (p *LinuxProcess) WriteBytes(addr uintptr, data []byte) error {
+	if _, err := p.memFile.WriteAt(data, int64(addr)); err != nil {
+		return fmt.Errorf("write failed at %08X: %v", addr, err)
+	}
+	return nil
+}
+
+func (p *LinuxProcess) QueryRegion(addr uintptr) (Region, error) {
+	maps, err := ProcMaps(p.Pid)
+	if err != nil {
+		return Region{}, err
+	}
+
+	next := ^uintptr(0)
+	for _, m := range maps {
+		if m.Contains(addr) {
+			return m.Region, nil
+		}
+		if m.Base > addr && m.Base < next {
+			next = m.Base
+		}
+	}
+	return Region{Base: addr, Size: next - addr}, nil
+}
+
+func (p *LinuxProcess) Regions() ([]Region, error) {
+	maps, err := ProcMaps(p.Pid)
+	if err != nil {
+		return nil, err
+	}
+	regions := make([]Region, len(maps))
+	for i, m := range maps {
+		regions[i] = m.Region
+	}
+	return regions, nil
+}
+
+func (p *LinuxProcess) Close() error {
+	if p.memFile == nil {
+		return nil
+	}
+	err := p.memFile.Close()
+	p.memFile = nil
+	return err
+}
+
+// Mapping is one line of /proc/<pid>/maps.
+type Mapping struct {
+	Region
+	Path string
+}
+
+// ProcMaps parses /proc/<pid>/maps.
+func ProcMaps(pid int) ([]Mapping, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var maps []Mapping
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 08048000-08056000 r-xp 00000000 03:0c 64593   /usr/sbin/gpm
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		bounds := strings.SplitN(fields[0], "-", 2)
+		if len(bounds) != 2 || len(fields[1]) < 4 {
+			continue
+		}
+		start, err1 := strconv.ParseUint(bounds[0], 16, 64)
+		end, err2 := strconv.ParseUint(bounds[1], 16, 64)
+		if err1 != nil || err2 != nil || end <= start {
+			continue
+		}
+
+		path := ""
+		if len(fields) >= 6 {
+			path = strings.Join(fields[5:], " ")
+		}
+
+		perms := fields[1]
+		anonymous := path == "" || path == "[heap]" || strings.HasPrefix(path, "[anon")
+		maps = append(maps, Mapping{
+			Region: Region{
+				Base:       uintptr(start),
+				Size:       uintptr(end - start),
+				Committed:  true,
+				Readable:   perms[0] == 'r',
+				Writable:   perms[1] == 'w',
+				Executable: perms[2] == 'x',
+				Private:    perms[3] == 'p' && anonymous,
+			},
+			Path: path,
+		})
+	}
+	return maps, scanner.Err()
+}
@@ -0,0 +1,114 @@
+//go:build linux
+
+package memory
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"unsafe"
+)
+
+// openSelf attaches to the test process itself, a helper with known memory.
+func openSelf(t *testing.T) *LinuxProcess {
+	t.Helper()
+	pm, err := OpenProcess(uint32(os.Getpid()))
+	if err != nil {
+		t.Skipf("procfs indisponível: %v", err)
+	}
+	t.Cleanup(func() { pm.Close() })
+	return pm.(*LinuxProcess)
+}
+
+func TestLinuxProcessReadBytes(t *testing.T) {
+	want := []byte("muletinha procfs read test \x01\x02\x03\xFF")
+	addr := uintptr(unsafe.Pointer(&want[0]))
+
+	for _, tt := range []struct {
+		name   string
+		vmRead bool
+	}{
+		{"process_vm_readv", true},
+		{"/proc/pid/mem", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := openSelf(t)
+			p.vmRead = tt.vmRead
+
+			got := make([]byte, len(want))
+			n, err := p.ReadBytes(addr, got)
+			if err != nil {
+				t.Fatalf("ReadBytes: %v", err)
+			}
+			if n != len(want) || !bytes.Equal(got, want) {
+				t.Fatalf("ReadBytes = %q (%d), want %q", got, n, want)
+			}
+		})
+	}
+
+	p := openSelf(t)
+	if _, err := p.ReadBytes(0x1000, make([]byte, 4)); err == nil {
+		t.Error("ReadBytes at an unmapped page succeeded")
+	}
+}
+
+func TestLinuxProcessQueryRegion(t *testing.T) {
+	p := openSelf(t)
+	buf := make([]byte, 64)
+	addr := uintptr(unsafe.Pointer(&buf[0]))
+
+	r, err := p.QueryRegion(addr)
+	if err != nil {
+		t.Fatalf("QueryRegion: %v", err)
+	}
+	if !r.Contains(addr) || !r.Committed || !r.Readable || !r.Writable {
+		t.Errorf("QueryRegion(%X) = %+v, want a committed rw region around it", addr, r)
+	}
+
+	// Abaixo de tudo: lacuna livre até o primeiro mapeamento
+	free, err := p.QueryRegion(0)
+	if err != nil {
+		t.Fatalf("QueryRegion(0): %v", err)
+	}
+	if free.Committed || free.Base != 0 || free.Size == 0 {
+		t.Errorf("QueryRegion(0) = %+v, want a free gap", free)
+	}
+}
+
+func TestProcMaps(t *testing.T) {
+	maps, err := ProcMaps(os.Getpid())
+	if err != nil {
+		t.Fatalf("ProcMaps: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O heap do Go é anônimo: vira região privada, como no Windows
+	heap := make([]byte, 1<<20)
+	heapAddr := uintptr(unsafe.Pointer(&heap[0]))
+
+	var code, private bool
+	for i, m := range maps {
+		if m.Size == 0 {
+			t.Errorf("mapping %d has no size: %+v", i, m)
+		}
+		if i > 0 && m.Base < maps[i-1].End() {
+			t.Errorf("mapping %d overlaps the previous one", i)
+		}
+		if m.Path == exe && m.Executable && m.Readable {
+			code = true
+		}
+		if m.Contains(heapAddr) {
+			private = m.Private && m.Writable && m.Path == ""
+		}
+	}
+	if !code {
+		t.Errorf("no executable mapping of %s", exe)
+	}
+	if !private {
+		t.Errorf("heap at %X not in a private anonymous rw mapping", heapAddr)
+	}
+}
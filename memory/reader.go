@@ -1,56 +1,75 @@
 package memory
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
-	"unsafe"
-
-	"golang.org/x/sys/windows"
 )
 
-var (
-	kernel32              = windows.NewLazySystemDLL("kernel32.dll")
-	ProcReadProcessMemory = kernel32.NewProc("ReadProcessMemory")
-)
+// ProcessMemory is the read side of a memory backend. Every reader in the
+// overlay goes through it, so the same parsing code runs against the live
+// process (Win32) or a fake/recorded memory image.
+type ProcessMemory interface {
+	// ReadBytes fills buf starting at addr and returns how many bytes were read.
+	ReadBytes(addr uintptr, buf []byte) (int, error)
+	// QueryRegion describes the memory region that contains addr.
+	QueryRegion(addr uintptr) (Region, error)
+	Close() error
+}
+
+// Writer is implemented by backends that can also write to the target.
+type Writer interface {
+	WriteBytes(addr uintptr, data []byte) error
+}
 
-func ReadMemoryBytes(handle windows.Handle, addr uintptr, buf []byte) error {
-	var bytesRead uintptr
-	ret, _, _ := ProcReadProcessMemory.Call(
-		uintptr(handle),
-		addr,
-		uintptr(unsafe.Pointer(&buf[0])),
-		uintptr(len(buf)),
-		uintptr(unsafe.Pointer(&bytesRead)),
-	)
-	if ret == 0 {
-		return fmt.Errorf("read failed")
+// Region describes a contiguous range of pages with the same attributes.
+type Region struct {
+	Base       uintptr
+	Size       uintptr
+	Committed  bool
+	Readable   bool
+	Writable   bool
+	Executable bool
+	Private    bool
+}
+
+func (r Region) End() uintptr {
+	return r.Base + r.Size
+}
+
+func (r Region) Contains(addr uintptr) bool {
+	return addr >= r.Base && addr < r.End()
+}
+
+func ReadMemoryBytes(pm ProcessMemory, addr uintptr, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	n, err := pm.ReadBytes(addr, buf)
+	if err != nil {
+		return err
+	}
+	if n < len(buf) {
+		return fmt.Errorf("short read at %08X: %d/%d", addr, n, len(buf))
 	}
 	return nil
 }
 
-func ReadU32(h windows.Handle, addr uintptr) uint32 {
-	var v uint32
-	ProcReadProcessMemory.Call(uintptr(h), addr, uintptr(unsafe.Pointer(&v)), 4, 0)
-	return v
+func ReadU32(pm ProcessMemory, addr uintptr) uint32 {
+	var b [4]byte
+	if ReadMemoryBytes(pm, addr, b[:]) != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b[:])
 }
 
-func ReadF32(h windows.Handle, addr uintptr) float32 {
-	var v float32
-	ProcReadProcessMemory.Call(uintptr(h), addr, uintptr(unsafe.Pointer(&v)), 4, 0)
-	return v
+func ReadF32(pm ProcessMemory, addr uintptr) float32 {
+	return math.Float32frombits(ReadU32(pm, addr))
 }
 
-func ReadString(handle windows.Handle, addr uintptr, maxLen int) string {
+func ReadString(pm ProcessMemory, addr uintptr, maxLen int) string {
 	buf := make([]byte, maxLen)
-	var bytesRead uintptr
-	ret, _, _ := ProcReadProcessMemory.Call(
-		uintptr(handle),
-		addr,
-		uintptr(unsafe.Pointer(&buf[0])),
-		uintptr(maxLen),
-		uintptr(unsafe.Pointer(&bytesRead)),
-	)
-	if ret == 0 {
+	if ReadMemoryBytes(pm, addr, buf) != nil {
 		return ""
 	}
 	for i, b := range buf {
@@ -65,7 +84,12 @@ func BytesToUint32(b []byte) uint32 {
 	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
 }
 
+// IsValidPtr checks ptr against the active AddressMap when one is set, and
+// against the usual heap range otherwise.
 func IsValidPtr(ptr uint32) bool {
+	if m := activeMap.Load(); m != nil {
+		return ptr >= 0x10000 && m.Readable(uintptr(ptr))
+	}
 	return ptr >= 0x10000000 && ptr < 0xF0000000
 }
 
@@ -84,19 +108,13 @@ func CalculateDistance(x1, y1, z1, x2, y2, z2 float32) float32 {
 }
 
 // WriteU32 writes a 32-bit unsigned integer to memory
-func WriteU32(handle windows.Handle, addr uintptr, value uint32) bool {
-	var bytesWritten uintptr
-
-	ret, _, _ := ProcWriteProcessMemory.Call(
-		uintptr(handle),
-		addr,
-		uintptr(unsafe.Pointer(&value)),
-		4,
-		uintptr(unsafe.Pointer(&bytesWritten)),
-	)
-
-	return ret != 0
-}
+func WriteU32(pm ProcessMemory, addr uintptr, value uint32) bool {
+	w, ok := pm.(Writer)
+	if !ok {
+		return false
+	}
 
-// ProcWriteProcessMemory is the WriteProcessMemory syscall
-var ProcWriteProcessMemory = windows.NewLazyDLL("kernel32.dll").NewProc("WriteProcessMemory")
\ No newline at end of file
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], value)
+	return w.WriteBytes(addr, b[:]) == nil
+}
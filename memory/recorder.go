@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const pageSize = 0x1000
+
+// Recorder wraps a backend and keeps a copy of every page the readers touch,
+// so the exact memory an overlay tick depends on can be saved and replayed.
+type Recorder struct {
+	inner   ProcessMemory
+	mu      sync.Mutex
+	pages   map[uintptr]RecordedPage
+	regions []Region
+}
+
+// RecordedPage is a captured range, usually one full page. Ranges at the
+// edge of readable memory may be shorter.
+type RecordedPage struct {
+	Base       uintptr
+	Data       []byte
+	CapturedAt time.Time
+}
+
+func NewRecorder(inner ProcessMemory) *Recorder {
+	return &Recorder{
+		inner: inner,
+		pages: make(map[uintptr]RecordedPage),
+	}
+}
+
+func (r *Recorder) ReadBytes(addr uintptr, buf []byte) (int, error) {
+	n, err := r.inner.ReadBytes(addr, buf)
+	if n > 0 {
+		r.record(addr, buf[:n])
+	}
+	return n, err
+}
+
+func (r *Recorder) record(addr uintptr, data []byte) {
+	now := time.Now()
+	end := addr + uintptr(len(data))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for page := addr &^ (pageSize - 1); page < end; page += pageSize {
+		if p, ok := r.pages[page]; ok && len(p.Data) == pageSize {
+			continue
+		}
+
+		// Page fully covered by this read: copy it straight from the buffer.
+		if page >= addr && page+pageSize <= end {
+			r.pages[page] = RecordedPage{Base: page, Data: append([]byte(nil), data[page-addr:page-addr+pageSize]...), CapturedAt: now}
+			continue
+		}
+
+		full := make([]byte, pageSize)
+		if ReadMemoryBytes(r.inner, page, full) == nil {
+			r.pages[page] = RecordedPage{Base: page, Data: full, CapturedAt: now}
+			continue
+		}
+
+		// Only part of the page is readable; keep what the caller got.
+		start := page
+		if start < addr {
+			start = addr
+		}
+		stop := page + pageSize
+		if stop > end {
+			stop = end
+		}
+		if p, ok := r.pages[page]; !ok || len(p.Data) < int(stop-start) {
+			r.pages[page] = RecordedPage{Base: start, Data: append([]byte(nil), data[start-addr:stop-addr]...), CapturedAt: now}
+		}
+	}
+}
+
+func (r *Recorder) QueryRegion(addr uintptr) (Region, error) {
+	return r.inner.QueryRegion(addr)
+}
+
+// Regions enumerates the wrapped backend and keeps the result, so the
+// snapshot carries the process map the scanner saw.
+func (r *Recorder) Regions() ([]Region, error) {
+	regions, err := Regions(r.inner)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.regions = regions
+	r.mu.Unlock()
+	return regions, nil
+}
+
+// RecordedRegions returns the last region list seen through Regions.
+func (r *Recorder) RecordedRegions() []Region {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.regions
+}
+
+// Close is a no-op: the wrapped backend still belongs to the caller.
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// Pages returns the captured pages ordered by address.
+func (r *Recorder) Pages() []RecordedPage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pages := make([]RecordedPage, 0, len(r.pages))
+	for _, p := range r.pages {
+		pages = append(pages, p)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Base < pages[j].Base
+	})
+	return pages
+}
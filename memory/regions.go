@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// RegionLister is implemented by backends that can list their whole address
+// space at once (procfs maps, snapshots). Others are walked with QueryRegion.
+type RegionLister interface {
+	Regions() ([]Region, error)
+}
+
+// Regions enumerates the address space of pm in address order, free gaps
+// included when the backend reports them.
+func Regions(pm ProcessMemory) ([]Region, error) {
+	if l, ok := pm.(RegionLister); ok {
+		return l.Regions()
+	}
+
+	var regions []Region
+	addr := uintptr(0)
+	for {
+		r, err := pm.QueryRegion(addr)
+		if err != nil {
+			// VirtualQueryEx falha ao passar do fim do espaço de usuário
+			if len(regions) == 0 {
+				return nil, err
+			}
+			break
+		}
+		if r.Size == 0 {
+			break
+		}
+		regions = append(regions, r)
+
+		next := r.End()
+		if next <= addr {
+			break
+		}
+		addr = next
+	}
+	return regions, nil
+}
+
+// IsHeap reports whether the scanner should visit r: committed, readable,
+// private memory (heap allocations, not mapped images or files).
+func (r Region) IsHeap() bool {
+	return r.Committed && r.Readable && r.Private
+}
+
+// AddressMap is a sorted copy of the readable regions of a process, used to
+// validate pointers against what is actually mapped.
+type AddressMap struct {
+	regions []Region
+}
+
+func NewAddressMap(regions []Region) *AddressMap {
+	m := &AddressMap{}
+	for _, r := range regions {
+		if r.Committed && r.Readable {
+			m.regions = append(m.regions, r)
+		}
+	}
+	sort.Slice(m.regions, func(i, j int) bool {
+		return m.regions[i].Base < m.regions[j].Base
+	})
+	return m
+}
+
+// LoadAddressMap enumerates pm and builds its map.
+func LoadAddressMap(pm ProcessMemory) (*AddressMap, error) {
+	regions, err := Regions(pm)
+	if err != nil {
+		return nil, err
+	}
+	return NewAddressMap(regions), nil
+}
+
+// Readable reports whether addr lies in a committed, readable region.
+func (m *AddressMap) Readable(addr uintptr) bool {
+	i := sort.Search(len(m.regions), func(i int) bool {
+		return m.regions[i].End() > addr
+	})
+	return i < len(m.regions) && m.regions[i].Base <= addr
+}
+
+// Heap returns the regions the entity scanner should visit.
+func (m *AddressMap) Heap() []Region {
+	var heap []Region
+	for _, r := range m.regions {
+		if r.IsHeap() {
+			heap = append(heap, r)
+		}
+	}
+	return heap
+}
+
+var activeMap atomic.Pointer[AddressMap]
+
+// SetAddressMap makes IsValidPtr check pointers against m. nil restores the
+// fixed range check.
+func SetAddressMap(m *AddressMap) {
+	activeMap.Store(m)
+}
+
+func ActiveAddressMap() *AddressMap {
+	return activeMap.Load()
+}
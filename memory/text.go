@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Encoding is how the game stores a string. The zero value is UTF-8, which
+// also covers plain ASCII.
+type Encoding string
+
+const (
+	UTF8    Encoding = "utf8"
+	UTF16LE Encoding = "utf16"
+)
+
+func ParseEncoding(s string) (Encoding, error) {
+	switch Encoding(s) {
+	case "", UTF8:
+		return UTF8, nil
+	case UTF16LE:
+		return UTF16LE, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q", s)
+}
+
+// UnitSize is the size in bytes of one code unit.
+func (e Encoding) UnitSize() int {
+	if e == UTF16LE {
+		return 2
+	}
+	return 1
+}
+
+// StringEncoder is optionally implemented by Symbols to pick the encoding of
+// string fields that don't set one in their tag.
+type StringEncoder interface {
+	StringEncoding() Encoding
+}
+
+// DecodeString converts a NUL-terminated buffer to a Go string. Invalid
+// sequences become U+FFFD, so validity checks can reject them.
+func DecodeString(b []byte, enc Encoding) string {
+	if enc == UTF16LE {
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u := binary.LittleEndian.Uint16(b[i:])
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		return string(utf16.Decode(units))
+	}
+
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+	}
+	return strings.ToValidUTF8(string(b), "\uFFFD")
+}
+
+// ReadStringEncoded reads up to maxChars code units at addr.
+func ReadStringEncoded(pm ProcessMemory, addr uintptr, maxChars int, enc Encoding) string {
+	buf := make([]byte, maxChars*enc.UnitSize())
+	if ReadMemoryBytes(pm, addr, buf) != nil {
+		return ""
+	}
+	return DecodeString(buf, enc)
+}
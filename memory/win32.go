@@ -0,0 +1,101 @@
+//go:build windows
+
+package memory
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32               = windows.NewLazySystemDLL("kernel32.dll")
+	ProcReadProcessMemory  = kernel32.NewProc("ReadProcessMemory")
+	ProcWriteProcessMemory = kernel32.NewProc("WriteProcessMemory")
+)
+
+const memPrivate = 0x20000
+
+// Win32Process reads and writes the target through ReadProcessMemory /
+// WriteProcessMemory.
+type Win32Process struct {
+	Handle windows.Handle
+}
+
+// OpenProcess opens pid with full access and returns the Win32 backend.
+func OpenProcess(pid uint32) (ProcessMemory, error) {
+	handle, err := windows.OpenProcess(0x1F0FFF, false, pid)
+	if err != nil {
+		return nil, err
+	}
+	return &Win32Process{Handle: handle}, nil
+}
+
+func (p *Win32Process) ReadBytes(addr uintptr, buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	var bytesRead uintptr
+	ret, _, _ := ProcReadProcessMemory.Call(
+		uintptr(p.Handle),
+		addr,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)),
+		uintptr(unsafe.Pointer(&bytesRead)),
+	)
+	if ret == 0 {
+		return int(bytesRead), fmt.Errorf("read failed at %08X", addr)
+	}
+	return int(bytesRead), nil
+}
+
+func (p *Win32Process) WriteBytes(addr uintptr, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var bytesWritten uintptr
+	ret, _, _ := ProcWriteProcessMemory.Call(
+		uintptr(p.Handle),
+		addr,
+		uintptr(unsafe.Pointer(&data[0])),
+		uintptr(len(data)),
+		uintptr(unsafe.Pointer(&bytesWritten)),
+	)
+	if ret == 0 {
+		return fmt.Errorf("write failed at %08X", addr)
+	}
+	return nil
+}
+
+func (p *Win32Process) QueryRegion(addr uintptr) (Region, error) {
+	var mbi windows.MemoryBasicInformation
+	if err := windows.VirtualQueryEx(p.Handle, addr, &mbi, unsafe.Sizeof(mbi)); err != nil {
+		return Region{}, err
+	}
+
+	protect := mbi.Protect &^ (windows.PAGE_GUARD | windows.PAGE_NOCACHE | windows.PAGE_WRITECOMBINE)
+	committed := mbi.State == windows.MEM_COMMIT
+	guarded := mbi.Protect&windows.PAGE_GUARD != 0
+
+	return Region{
+		Base:       mbi.BaseAddress,
+		Size:       mbi.RegionSize,
+		Committed:  committed,
+		Readable:   committed && !guarded && protect != windows.PAGE_NOACCESS && protect != 0,
+		Writable:   protect&(windows.PAGE_READWRITE|windows.PAGE_WRITECOPY|windows.PAGE_EXECUTE_READWRITE|windows.PAGE_EXECUTE_WRITECOPY) != 0,
+		Executable: protect&(windows.PAGE_EXECUTE|windows.PAGE_EXECUTE_READ|windows.PAGE_EXECUTE_READWRITE|windows.PAGE_EXECUTE_WRITECOPY) != 0,
+		Private:    mbi.Type == memPrivate,
+	}, nil
+}
+
+func (p *Win32Process) Close() error {
+	if p.Handle == 0 {
+		return nil
+	}
+	err := windows.CloseHandle(p.Handle)
+	p.Handle = 0
+	return err
+}
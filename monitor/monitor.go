@@ -1,21 +1,18 @@
 package monitor
 
 import (
-	"encoding/json"
-	"fmt"
-	"muletinha/config"
-	"muletinha/input"
-	"os"
 	"time"
 )
 
 // ================== BUFF INFO ==================
 
+// Campos com tag `mem` vêm de cada entrada do array de buffs (memory.DecodeBytes)
 type BuffInfo struct {
 	Index    int
-	ID       uint32
-	Duration uint32
-	TimeLeft uint32
+	ID       uint32 `mem:"buff.id"`
+	Duration uint32 `mem:"buff.duration"`
+	TimeLeft uint32 `mem:"buff.time_left"`
+	Stack    uint32 `mem:"buff.stack,opt"` // 0 = perfil sem buff.stack
 	Name     string
 }
 
@@ -31,10 +28,11 @@ type BuffEvent struct {
 
 type DebuffInfo struct {
 	Index   int
-	ID      uint32
-	TypeID  uint32
-	DurMax  uint32
-	DurLeft uint32
+	ID      uint32 `mem:"debuff.id"`
+	TypeID  uint32 `mem:"debuff.type_id"`
+	DurMax  uint32 `mem:"debuff.duration"`
+	DurLeft uint32 `mem:"debuff.time_left"`
+	Stack   uint32 `mem:"debuff.stack,opt"` // 0 = perfil sem debuff.stack
 	CCName  string
 }
 
@@ -51,254 +49,98 @@ func MakeKey(id, typeID uint32) uint64 {
 	return (uint64(id) << 32) | uint64(typeID)
 }
 
-// ================== BUFF WHITELIST ==================
-
-type BuffWhitelistEntry struct {
-	Type     uint32         `json:"type"`
-	Name     string         `json:"name"`
-	Use      string         `json:"use"`
-	KeyCombo input.KeyCombo `json:"-"`
-}
-
-type BuffWhitelist struct {
-	Entries      []BuffWhitelistEntry
-	TypeMap      map[uint32]*BuffWhitelistEntry
-	Enabled      bool
-	Reactions    int
-	SpamCount    int
-	SpamInterval time.Duration
-	lastSpamTime time.Time
-	spamCooldown time.Duration
+// EntryState acompanha uma entrada de buff/debuff entre ticks, para os
+// triggers refreshed, stack_changed e expiring.
+type EntryState struct {
+	TimeLeft uint32 // ms, do último tick
+	Duration uint32 // ms, duração total do último tick
+	Stack    uint32 // acúmulos do último tick; 0 = desconhecido
+	Expiring bool   // expiring já reagiu desde a última aplicação
 }
 
-func NewBuffWhitelist() *BuffWhitelist {
-	wl := &BuffWhitelist{
-		Entries:      make([]BuffWhitelistEntry, 0),
-		TypeMap:      make(map[uint32]*BuffWhitelistEntry),
-		Enabled:      true,
-		SpamCount:    config.KEY_SPAM_COUNT,
-		SpamInterval: config.KEY_SPAM_INTERVAL,
-		spamCooldown: 100 * time.Millisecond,
-	}
-	wl.LoadFromFile("buff_whitelist.json")
-	return wl
+func NewEntryState(timeLeft, duration, stack uint32) *EntryState {
+	return &EntryState{TimeLeft: timeLeft, Duration: duration, Stack: stack}
 }
 
-func (wl *BuffWhitelist) LoadFromFile(filename string) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		wl.createDefaultFile(filename)
-		return
-	}
+// Update records the entry's new values and reports whether it was
+// re-applied (TimeLeft went back up or the total duration changed) and
+// whether its stack count changed. A re-application or a new stack re-arms
+// the expiring trigger.
+func (s *EntryState) Update(timeLeft, duration, stack uint32) (refreshed, stacked bool) {
+	refreshed = timeLeft > s.TimeLeft+refreshMargin ||
+		(s.Duration != 0 && duration != 0 && duration != s.Duration)
+	stacked = stack != s.Stack
 
-	var entries []BuffWhitelistEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		fmt.Printf("[BUFF] Erro JSON: %v\n", err)
-		return
+	s.TimeLeft, s.Duration, s.Stack = timeLeft, duration, stack
+	if refreshed || stacked {
+		s.Expiring = false
 	}
-
-	wl.Entries = entries
-	wl.TypeMap = make(map[uint32]*BuffWhitelistEntry)
-
-	for i := range wl.Entries {
-		wl.Entries[i].KeyCombo = input.ParseKeyCombo(wl.Entries[i].Use)
-		if wl.Entries[i].KeyCombo.MainKey != 0 {
-			wl.TypeMap[wl.Entries[i].Type] = &wl.Entries[i]
-		}
-	}
-
-	fmt.Printf("[BUFF] Carregado %d buffs da whitelist\n", len(wl.Entries))
+	return refreshed, stacked
 }
 
-func (wl *BuffWhitelist) createDefaultFile(filename string) {
-	defaultEntries := []BuffWhitelistEntry{
-		{Type: 87, Name: "Hell Spear", Use: "F10"},
-		{Type: 243, Name: "stun", Use: "SHIFT+1"},
-		{Type: 156, Name: "Fear", Use: "CTRL+2"},
-		{Type: 21402, Name: "Deafened", Use: "ALT+F1"},
-		{Type: 8000210, Name: "Clash Dummy", Use: "SHIFT+5"},
-		{Type: 21, Name: "Tripped (Strong)", Use: "CTRL+SHIFT+1"},
-		{Type: 141, Name: "Tripped", Use: "9"},
-		{Type: 6860, Name: "Impaled", Use: "SHIFT+F10"},
-		{Type: 18396, Name: "Skewer", Use: "F10"},
-		{Type: 2458, Name: "Snare (charge)", Use: "F11"},
-		{Type: 6829, Name: "Throw Dagger", Use: "CTRL+F11"},
-		{Type: 501, Name: "Shield Slam", Use: "F10"},
-		{Type: 3601, Name: "Overrun", Use: "SHIFT+F12"},
-	}
-
-	data, _ := json.MarshalIndent(defaultEntries, "", "  ")
-	os.WriteFile(filename, data, 0644)
-	fmt.Printf("[BUFF] Criado arquivo %s com %d entradas padrão\n", filename, len(defaultEntries))
-
-	wl.Entries = defaultEntries
-	wl.TypeMap = make(map[uint32]*BuffWhitelistEntry)
-	for i := range wl.Entries {
-		wl.Entries[i].KeyCombo = input.ParseKeyCombo(wl.Entries[i].Use)
-		if wl.Entries[i].KeyCombo.MainKey != 0 {
-			wl.TypeMap[wl.Entries[i].Type] = &wl.Entries[i]
-		}
-	}
-}
-
-func (wl *BuffWhitelist) ReactInstant(buffID uint32) (bool, string) {
-	if !wl.Enabled {
-		return false, ""
-	}
-
-	entry, exists := wl.TypeMap[buffID]
-	if !exists {
-		return false, ""
-	}
-
-	if time.Since(wl.lastSpamTime) < wl.spamCooldown {
-		return false, ""
+// react runs ctx through rules when the break is enabled and hands the
+// reaction to stats for verification.
+func react(enabled bool, rules *RuleEngine, stats *ReactionStats, ctx Context, reactions *int) (bool, *Rule, *Action) {
+	if !enabled || rules == nil {
+		return false, nil, nil
 	}
-
-	wl.lastSpamTime = time.Now()
-	go input.SpamKey(entry.KeyCombo.RawString, wl.SpamCount, wl.SpamInterval)  // <- aqui
-
-	wl.Reactions++
-	return true, entry.Name
-}
-
-func (wl *BuffWhitelist) GetName(buffID uint32) string {
-	if entry, exists := wl.TypeMap[buffID]; exists {
-		return entry.Name
+	reacted, rule, action := rules.React(ctx)
+	if reacted {
+		*reactions++
+		stats.Reacted(ctx.Source, ctx.Key, rule, ctx.TimeLeft, time.Now())
 	}
-	return ""
+	return reacted, rule, action
 }
 
-// ================== CC WHITELIST ==================
-
-type CCWhitelistEntry struct {
-	Type     uint32         `json:"type"`
-	Name     string         `json:"name"`
-	Use      string         `json:"use"`
-	KeyCombo input.KeyCombo `json:"-"`
-}
+// ================== BUFF MONITOR ==================
 
-type CCWhitelist struct {
-	Entries      []CCWhitelistEntry
-	TypeMap      map[uint32]*CCWhitelistEntry
+type BuffMonitor struct {
 	Enabled      bool
+	BuffListAddr uintptr
+	Buffs        []BuffInfo
+	KnownIDs     map[uint32]*EntryState
+	Events       []BuffEvent
+	MaxEvents    int
+	RawCount     uint32
+
+	// Regras com source "buff"; BreakEnabled é o toggle Buff Break
+	Rules        *RuleEngine
+	BreakEnabled bool
 	Reactions    int
-	SpamCount    int
-	SpamInterval time.Duration
-	lastSpamTime time.Time
-	spamCooldown time.Duration
+	Stats        *ReactionStats
 }
 
-func NewCCWhitelist() *CCWhitelist {
-	wl := &CCWhitelist{
-		Entries:      make([]CCWhitelistEntry, 0),
-		TypeMap:      make(map[uint32]*CCWhitelistEntry),
+func NewBuffMonitor(rules *RuleEngine, stats *ReactionStats) *BuffMonitor {
+	return &BuffMonitor{
 		Enabled:      true,
-		SpamCount:    config.KEY_SPAM_COUNT,
-		SpamInterval: config.KEY_SPAM_INTERVAL,
-		spamCooldown: 100 * time.Millisecond,
+		KnownIDs:     make(map[uint32]*EntryState),
+		Events:       make([]BuffEvent, 0, 20),
+		MaxEvents:    20,
+		Rules:        rules,
+		BreakEnabled: true,
+		Stats:        stats,
 	}
-	wl.LoadFromFile("cc_whitelist.json")
-	return wl
 }
 
-func (wl *CCWhitelist) LoadFromFile(filename string) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		wl.createDefaultFile(filename)
-		return
-	}
-
-	var entries []CCWhitelistEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		fmt.Printf("[CC] Erro JSON: %v\n", err)
-		return
-	}
-
-	wl.Entries = entries
-	wl.TypeMap = make(map[uint32]*CCWhitelistEntry)
-
-	for i := range wl.Entries {
-		wl.Entries[i].KeyCombo = input.ParseKeyCombo(wl.Entries[i].Use)
-		if wl.Entries[i].KeyCombo.MainKey != 0 {
-			wl.TypeMap[wl.Entries[i].Type] = &wl.Entries[i]
-		}
-	}
-
-	fmt.Printf("[CC] Carregado %d CCs\n", len(wl.Entries))
+// React evaluates ctx against the buff rules.
+func (m *BuffMonitor) React(ctx Context) (bool, *Rule, *Action) {
+	ctx.Source = SourceBuff
+	ctx.Key = uint64(ctx.ID)
+	return react(m.BreakEnabled, m.Rules, m.Stats, ctx, &m.Reactions)
 }
 
-func (wl *CCWhitelist) createDefaultFile(filename string) {
-	defaultEntries := []CCWhitelistEntry{
-		{Type: 3601, Name: "stun", Use: "F12"},
-		{Type: 509, Name: "knockdown", Use: "SHIFT+F12"},
-		{Type: 4622, Name: "sleep", Use: "CTRL+F11"},
-		{Type: 6800, Name: "fear", Use: "F12"},
-		{Type: 20121, Name: "silence", Use: "SHIFT+1"},
-		{Type: 22290, Name: "root", Use: "CTRL+2"},
-	}
-
-	data, _ := json.MarshalIndent(defaultEntries, "", "  ")
-	os.WriteFile(filename, data, 0644)
-
-	wl.Entries = defaultEntries
-	wl.TypeMap = make(map[uint32]*CCWhitelistEntry)
-	for i := range wl.Entries {
-		wl.Entries[i].KeyCombo = input.ParseKeyCombo(wl.Entries[i].Use)
-		if wl.Entries[i].KeyCombo.MainKey != 0 {
-			wl.TypeMap[wl.Entries[i].Type] = &wl.Entries[i]
-		}
-	}
+// Remove drops a buff that left the list and settles its pending reaction.
+func (m *BuffMonitor) Remove(id uint32, now time.Time) {
+	delete(m.KnownIDs, id)
+	m.Stats.Cleared(SourceBuff, uint64(id), now)
 }
 
-func (wl *CCWhitelist) ReactInstant(typeID uint32) (bool, string) {
-	if !wl.Enabled {
-		return false, ""
-	}
-
-	entry, exists := wl.TypeMap[typeID]
-	if !exists {
-		return false, ""
-	}
-
-	if time.Since(wl.lastSpamTime) < wl.spamCooldown {
-		return false, ""
-	}
-
-	wl.lastSpamTime = time.Now()
-	go input.SpamKey(entry.KeyCombo.RawString, wl.SpamCount, wl.SpamInterval)  // <- aqui
-
-	wl.Reactions++
-	return true, entry.Name
-}
-
-func (wl *CCWhitelist) GetName(typeID uint32) string {
-	if entry, exists := wl.TypeMap[typeID]; exists {
-		return entry.Name
-	}
-	return ""
-}
-
-// ================== BUFF MONITOR ==================
-
-type BuffMonitor struct {
-	Enabled      bool
-	BuffListAddr uintptr
-	Buffs        []BuffInfo
-	KnownIDs     map[uint32]bool
-	Events       []BuffEvent
-	MaxEvents    int
-	RawCount     uint32
-	Whitelist    *BuffWhitelist
-}
-
-func NewBuffMonitor() *BuffMonitor {
-	return &BuffMonitor{
-		Enabled:   true,
-		KnownIDs:  make(map[uint32]bool),
-		Events:    make([]BuffEvent, 0, 20),
-		MaxEvents: 20,
-		Whitelist: NewBuffWhitelist(),
+// Reset forgets every buff after a failed or garbage read of the list; the
+// pending reactions are dropped, not settled.
+func (m *BuffMonitor) Reset() {
+	for id := range m.KnownIDs {
+		delete(m.KnownIDs, id)
+		m.Stats.Forget(SourceBuff, uint64(id))
 	}
 }
 
@@ -320,24 +162,63 @@ func (m *BuffMonitor) AddEvent(eventType string, id uint32, name string, reacted
 // ================== DEBUFF MONITOR ==================
 
 type DebuffMonitor struct {
-	Enabled     bool
-	DebuffBase  uintptr
-	Debuffs     []DebuffInfo
-	KnownIDs    map[uint64]bool
-	Events      []DebuffEvent
-	MaxEvents   int
-	RawCount    uint32
-	CCWhitelist *CCWhitelist
+	Enabled    bool
+	DebuffBase uintptr
+	Debuffs    []DebuffInfo
+	KnownIDs   map[uint64]*EntryState
+	Events     []DebuffEvent
+	MaxEvents  int
+	RawCount   uint32
+
+	// Regras com source "debuff"; BreakEnabled é o toggle CC Break
+	Rules        *RuleEngine
+	BreakEnabled bool
+	Reactions    int
+	Stats        *ReactionStats
 }
 
-func NewDebuffMonitor() *DebuffMonitor {
+func NewDebuffMonitor(rules *RuleEngine, stats *ReactionStats) *DebuffMonitor {
 	return &DebuffMonitor{
-		Enabled:     true,
-		KnownIDs:    make(map[uint64]bool),
-		Events:      make([]DebuffEvent, 0, 20),
-		MaxEvents:   20,
-		CCWhitelist: NewCCWhitelist(),
+		Enabled:      true,
+		KnownIDs:     make(map[uint64]*EntryState),
+		Events:       make([]DebuffEvent, 0, 20),
+		MaxEvents:    20,
+		Rules:        rules,
+		BreakEnabled: true,
+		Stats:        stats,
+	}
+}
+
+// React evaluates ctx (Key = MakeKey(id, typeID)) against the debuff rules.
+func (m *DebuffMonitor) React(ctx Context) (bool, *Rule, *Action) {
+	ctx.Source = SourceDebuff
+	return react(m.BreakEnabled, m.Rules, m.Stats, ctx, &m.Reactions)
+}
+
+// Remove drops a debuff that left the list and settles its pending reaction.
+func (m *DebuffMonitor) Remove(key uint64, now time.Time) {
+	delete(m.KnownIDs, key)
+	m.Stats.Cleared(SourceDebuff, key, now)
+}
+
+// Reset forgets every debuff after a failed or garbage read of the list; the
+// pending reactions are dropped, not settled.
+func (m *DebuffMonitor) Reset() {
+	for key := range m.KnownIDs {
+		delete(m.KnownIDs, key)
+		m.Stats.Forget(SourceDebuff, key)
+	}
+}
+
+// CCCount is how many of debuffs are covered by a debuff rule.
+func (m *DebuffMonitor) CCCount(debuffs []DebuffInfo) int {
+	n := 0
+	for _, d := range debuffs {
+		if m.Rules != nil && m.Rules.Matches(SourceDebuff, d.TypeID) {
+			n++
+		}
 	}
+	return n
 }
 
 func (m *DebuffMonitor) AddEvent(eventType string, id, typeID uint32, ccName string, reacted bool) {
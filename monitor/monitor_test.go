@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"muletinha/memory"
+	"muletinha/offsets"
+	"testing"
+)
+
+const testArray = 0x20000000
+
+func putU32(b []byte, off, v uint32) {
+	binary.LittleEndian.PutUint32(b[off:], v)
+}
+
+func TestDecodeBuffInfo(t *testing.T) {
+	stacked := offsets.Default()
+	stacked.Buff.Stack = 0x38
+
+	tests := []struct {
+		name    string
+		profile *offsets.Profile
+		fill    func(p *offsets.Profile, e []byte)
+		want    BuffInfo
+	}{
+		{
+			name:    "default offsets",
+			profile: offsets.Default(),
+			fill: func(p *offsets.Profile, e []byte) {
+				putU32(e, p.Buff.ID, 12345)
+				putU32(e, p.Buff.Duration, 30000)
+				putU32(e, p.Buff.TimeLeft, 12500)
+			},
+			want: BuffInfo{ID: 12345, Duration: 30000, TimeLeft: 12500},
+		},
+		{
+			name:    "stack absent from profile",
+			profile: offsets.Default(),
+			fill: func(p *offsets.Profile, e []byte) {
+				putU32(e, p.Buff.ID, 2000)
+				putU32(e, 0x38, 7)
+			},
+			want: BuffInfo{ID: 2000},
+		},
+		{
+			name:    "stack offset",
+			profile: stacked,
+			fill: func(p *offsets.Profile, e []byte) {
+				putU32(e, p.Buff.ID, 2000)
+				putU32(e, p.Buff.Stack, 3)
+			},
+			want: BuffInfo{ID: 2000, Stack: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.profile
+			entry := make([]byte, p.Buff.Size)
+			tt.fill(p, entry)
+
+			// Entrada lida em bloco (DecodeBytes) e direto da memória (Decode)
+			var fromBytes BuffInfo
+			if err := memory.DecodeBytes(nil, entry, testArray, &fromBytes, p); err != nil {
+				t.Fatalf("DecodeBytes: %v", err)
+			}
+			if fromBytes != tt.want {
+				t.Errorf("DecodeBytes = %+v, want %+v", fromBytes, tt.want)
+			}
+
+			img := memory.NewImage()
+			img.Map(testArray, entry)
+			var fromMem BuffInfo
+			if err := memory.Decode(img, testArray, &fromMem, p); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if fromMem != tt.want {
+				t.Errorf("Decode = %+v, want %+v", fromMem, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDebuffInfo(t *testing.T) {
+	p := offsets.Default()
+	size := p.Debuff.Size
+
+	// Três entradas seguidas, como o array do jogo
+	want := []DebuffInfo{
+		{ID: 10, TypeID: 5001, DurMax: 3000, DurLeft: 2500},
+		{ID: 11, TypeID: 87, DurMax: 8000, DurLeft: 100},
+		{ID: 12, TypeID: 1, DurMax: 1000, DurLeft: 1000},
+	}
+	array := make([]byte, int(size)*len(want))
+	for i, d := range want {
+		e := array[i*int(size):]
+		putU32(e, p.Debuff.ID, d.ID)
+		putU32(e, p.Debuff.TypeID, d.TypeID)
+		putU32(e, p.Debuff.Duration, d.DurMax)
+		putU32(e, p.Debuff.TimeLeft, d.DurLeft)
+	}
+	img := memory.NewImage()
+	img.Map(testArray, array)
+
+	for i, w := range want {
+		addr := uintptr(testArray + i*int(size))
+		var got DebuffInfo
+		if err := memory.Decode(img, addr, &got, p); err != nil {
+			t.Fatalf("entry %d: Decode: %v", i, err)
+		}
+		if got != w {
+			t.Errorf("entry %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// Entrada cortada no fim do bloco lido
+	var short DebuffInfo
+	if err := memory.DecodeBytes(nil, array[:p.Debuff.TimeLeft], testArray, &short, p); err == nil {
+		t.Errorf("DecodeBytes on a truncated entry succeeded: %+v", short)
+	}
+}
@@ -0,0 +1,553 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"muletinha/config"
+	"muletinha/input"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RulesFile substitui cc_whitelist.json e buff_whitelist.json, que são
+// convertidos automaticamente na primeira execução.
+const RulesFile = "rules.json"
+
+// Source is the list a rule watches: the player's buffs (by buff ID) or
+// debuffs (by type ID).
+type Source string
+
+const (
+	SourceBuff   Source = "buff"
+	SourceDebuff Source = "debuff"
+)
+
+// Trigger is the moment a rule is evaluated for an entry.
+type Trigger string
+
+const (
+	TriggerAppeared  Trigger = "appeared"  // entrada nova na lista
+	TriggerRefreshed Trigger = "refreshed" // TimeLeft voltou a subir ou a duração mudou (reaplicado)
+	TriggerExpiring  Trigger = "expiring"  // TimeLeft abaixo de remaining_max_ms
+
+	// Acúmulos mudaram (perfil com buff.stack/debuff.stack)
+	TriggerStackChanged Trigger = "stack_changed"
+)
+
+// Tolerância para não confundir jitter de leitura com reaplicação
+const refreshMargin = 500 // ms
+
+// IDRange is "1000-1999" or a single ID in JSON, inclusive.
+type IDRange struct {
+	Min, Max uint32
+}
+
+func (r IDRange) MarshalJSON() ([]byte, error) {
+	if r.Min == r.Max {
+		return json.Marshal(strconv.FormatUint(uint64(r.Min), 10))
+	}
+	return json.Marshal(fmt.Sprintf("%d-%d", r.Min, r.Max))
+}
+
+func (r *IDRange) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	lo, hi, found := strings.Cut(s, "-")
+	if !found {
+		hi = lo
+	}
+	min, err := strconv.ParseUint(strings.TrimSpace(lo), 10, 32)
+	if err != nil {
+		return fmt.Errorf("faixa de IDs %q: %v", s, err)
+	}
+	max, err := strconv.ParseUint(strings.TrimSpace(hi), 10, 32)
+	if err != nil {
+		return fmt.Errorf("faixa de IDs %q: %v", s, err)
+	}
+	if min > max {
+		return fmt.Errorf("faixa de IDs %q invertida", s)
+	}
+	r.Min, r.Max = uint32(min), uint32(max)
+	return nil
+}
+
+// Conditions are checked against the player state when a rule triggers.
+// Zero values are "no limit".
+type Conditions struct {
+	HPMin float32 `json:"hp_min,omitempty"` // % do HP máximo
+	HPMax float32 `json:"hp_max,omitempty"`
+	MPMin float32 `json:"mp_min,omitempty"`
+	MPMax float32 `json:"mp_max,omitempty"`
+
+	Mounted *bool `json:"mounted,omitempty"`
+
+	RemainingMinMs uint32 `json:"remaining_min_ms,omitempty"`
+	RemainingMaxMs uint32 `json:"remaining_max_ms,omitempty"`
+
+	// Debuffs ativos que batem alguma regra de debuff (CCs empilhados)
+	MinCCs int `json:"min_ccs,omitempty"`
+
+	// Acúmulos da própria entrada; sem buff.stack/debuff.stack no perfil
+	// nunca bate
+	StackMin uint32 `json:"stack_min,omitempty"`
+}
+
+type Action struct {
+	Key string `json:"key"`
+
+	// Recarga desta tecla; 0 = cooldown_ms da regra
+	CooldownMs int `json:"cooldown_ms,omitempty"`
+
+	combo input.KeyCombo
+}
+
+// Rule reacts to an entry of its Source. It matches when the entry's ID is
+// in Types or Ranges, or its catalog name matches a pattern; a rule with no
+// match criteria matches any entry.
+type Rule struct {
+	Name    string  `json:"name"`
+	Source  Source  `json:"source"`
+	Trigger Trigger `json:"trigger,omitempty"` // padrão appeared
+
+	Types    []uint32  `json:"types,omitempty"`
+	Ranges   []IDRange `json:"ranges,omitempty"`
+	Patterns []string  `json:"patterns,omitempty"` // regex no nome, sem diferenciar maiúsculas
+
+	Conditions *Conditions `json:"conditions,omitempty"`
+
+	// Uma tecla (action) ou uma lista em ordem de preferência (actions): a
+	// primeira fora de cooldown é usada
+	Action  *Action  `json:"action,omitempty"`
+	Actions []Action `json:"actions,omitempty"`
+
+	// Entre as regras que batem, a de maior prioridade reage (empate: a
+	// primeira do arquivo)
+	Priority int `json:"priority,omitempty"`
+
+	// Tempo de recarga da habilidade: a tecla não é usada de novo, por
+	// nenhuma regra, antes disso. 0 = só o mínimo de 100ms
+	CooldownMs int `json:"cooldown_ms,omitempty"`
+
+	// 0 = config.KEY_SPAM_COUNT / config.KEY_SPAM_INTERVAL
+	SpamCount      int `json:"spam_count,omitempty"`
+	SpamIntervalMs int `json:"spam_interval_ms,omitempty"`
+
+	patterns    []*regexp.Regexp
+	actions     []*Action // Action seguida de Actions
+	lastNoBreak time.Time // último aviso de todas em cooldown
+}
+
+func (r *Rule) compile() error {
+	switch r.Source {
+	case SourceBuff, SourceDebuff:
+	default:
+		return fmt.Errorf("source %q inválido", r.Source)
+	}
+	switch r.Trigger {
+	case "":
+		r.Trigger = TriggerAppeared
+	case TriggerAppeared, TriggerRefreshed, TriggerExpiring, TriggerStackChanged:
+	default:
+		return fmt.Errorf("trigger %q inválido", r.Trigger)
+	}
+	if r.Trigger == TriggerExpiring && (r.Conditions == nil || r.Conditions.RemainingMaxMs == 0) {
+		return fmt.Errorf("trigger expiring sem remaining_max_ms")
+	}
+
+	r.patterns = r.patterns[:0]
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	r.actions = nil
+	if r.Action != nil {
+		r.actions = append(r.actions, r.Action)
+	}
+	for i := range r.Actions {
+		r.actions = append(r.actions, &r.Actions[i])
+	}
+	if len(r.actions) == 0 {
+		return fmt.Errorf("sem action")
+	}
+	for _, a := range r.actions {
+		a.combo = input.ParseKeyCombo(a.Key)
+		if a.combo.MainKey == 0 {
+			return fmt.Errorf("tecla %q inválida", a.Key)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) cooldown(a *Action) time.Duration {
+	ms := a.CooldownMs
+	if ms == 0 {
+		ms = r.CooldownMs
+	}
+	if d := time.Duration(ms) * time.Millisecond; d > minKeyCooldown {
+		return d
+	}
+	return minKeyCooldown
+}
+
+func (r *Rule) matchesEntry(id uint32, name string) bool {
+	if len(r.Types) == 0 && len(r.Ranges) == 0 && len(r.patterns) == 0 {
+		return true
+	}
+	for _, t := range r.Types {
+		if t == id {
+			return true
+		}
+	}
+	for _, rg := range r.Ranges {
+		if id >= rg.Min && id <= rg.Max {
+			return true
+		}
+	}
+	if name != "" {
+		for _, re := range r.patterns {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PlayerState is what the conditions look at, filled by the game each tick.
+type PlayerState struct {
+	HP, MaxHP uint32
+	MP, MaxMP uint32
+	Mounted   bool
+}
+
+// Context is one entry at one trigger.
+type Context struct {
+	Source   Source
+	Trigger  Trigger
+	ID       uint32 // buff ID ou type ID do debuff
+	Key      uint64 // chave da entrada em KnownIDs, para a verificação
+	TimeLeft uint32 // ms
+	Stack    uint32 // 0 = desconhecido
+	CCs      int
+	Player   PlayerState
+}
+
+func percent(cur, max uint32) (float32, bool) {
+	if max == 0 {
+		return 0, false
+	}
+	return float32(cur) * 100 / float32(max), true
+}
+
+func inRange(v, min, max float32) bool {
+	return (min == 0 || v >= min) && (max == 0 || v <= max)
+}
+
+func (c *Conditions) check(ctx Context) bool {
+	if c == nil {
+		return true
+	}
+	if c.HPMin != 0 || c.HPMax != 0 {
+		hp, ok := percent(ctx.Player.HP, ctx.Player.MaxHP)
+		if !ok || !inRange(hp, c.HPMin, c.HPMax) {
+			return false
+		}
+	}
+	if c.MPMin != 0 || c.MPMax != 0 {
+		mp, ok := percent(ctx.Player.MP, ctx.Player.MaxMP)
+		if !ok || !inRange(mp, c.MPMin, c.MPMax) {
+			return false
+		}
+	}
+	if c.Mounted != nil && *c.Mounted != ctx.Player.Mounted {
+		return false
+	}
+	if c.RemainingMinMs != 0 && ctx.TimeLeft < c.RemainingMinMs {
+		return false
+	}
+	if c.RemainingMaxMs != 0 && ctx.TimeLeft > c.RemainingMaxMs {
+		return false
+	}
+	if ctx.Stack < c.StackMin {
+		return false
+	}
+	return ctx.CCs >= c.MinCCs
+}
+
+// RuleEngine holds the reaction rules for both monitors. Names labels IDs
+// for display and for the rules' patterns.
+type RuleEngine struct {
+	Rules []Rule                       `json:"rules"`
+	Names map[Source]map[uint32]string `json:"names,omitempty"`
+
+	// Padrões para regras sem spam_count/spam_interval_ms
+	SpamCount    int           `json:"-"`
+	SpamInterval time.Duration `json:"-"`
+
+	// Quando cada tecla (KeyCombo normalizado) pode ser usada de novo
+	keyReady map[string]time.Time
+}
+
+// Mínimo entre dois usos da mesma tecla, para reações do mesmo tick não
+// repetirem o spam
+const minKeyCooldown = 100 * time.Millisecond
+
+func NewRuleEngine() *RuleEngine {
+	e := &RuleEngine{
+		SpamCount:    config.KEY_SPAM_COUNT,
+		SpamInterval: config.KEY_SPAM_INTERVAL,
+		keyReady:     make(map[string]time.Time),
+	}
+	e.LoadFromFile(RulesFile)
+	return e
+}
+
+func (e *RuleEngine) LoadFromFile(filename string) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		e.migrate(filename)
+		e.compile()
+		return
+	}
+
+	if err := json.Unmarshal(data, e); err != nil {
+		fmt.Printf("[RULES] Erro JSON: %v\n", err)
+		return
+	}
+	e.compile()
+
+	fmt.Printf("[RULES] Carregadas %d regras (%d buff, %d debuff)\n", len(e.Rules), e.Count(SourceBuff), e.Count(SourceDebuff))
+}
+
+// compile drops (and reports) the rules that can't be used.
+func (e *RuleEngine) compile() {
+	rules := e.Rules[:0]
+	for _, r := range e.Rules {
+		if err := r.compile(); err != nil {
+			fmt.Printf("[RULES] Regra %q ignorada: %v\n", r.Name, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	e.Rules = rules
+}
+
+func (e *RuleEngine) SaveToFile(filename string) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Formato antigo: lista de {type, name, use}
+type legacyEntry struct {
+	Type uint32 `json:"type"`
+	Name string `json:"name"`
+	Use  string `json:"use"`
+}
+
+var legacyFiles = []struct {
+	file   string
+	source Source
+}{
+	{"cc_whitelist.json", SourceDebuff},
+	{"buff_whitelist.json", SourceBuff},
+}
+
+// migrate builds filename from the old whitelists (renamed to .migrated
+// afterwards), or from the defaults when there are none.
+func (e *RuleEngine) migrate(filename string) {
+	var migrated []string
+	for _, lf := range legacyFiles {
+		data, err := os.ReadFile(lf.file)
+		if err != nil {
+			continue
+		}
+		var entries []legacyEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			fmt.Printf("[RULES] %s: %v, não migrado\n", lf.file, err)
+			continue
+		}
+		e.addLegacy(lf.source, entries)
+		migrated = append(migrated, lf.file)
+		fmt.Printf("[RULES] Migrado %s (%d regras)\n", lf.file, len(entries))
+	}
+
+	if len(migrated) == 0 {
+		e.addLegacy(SourceDebuff, defaultCCRules)
+		e.addLegacy(SourceBuff, defaultBuffRules)
+	}
+
+	if err := e.SaveToFile(filename); err != nil {
+		fmt.Printf("[RULES] Erro ao criar %s: %v\n", filename, err)
+		return
+	}
+	fmt.Printf("[RULES] Criado arquivo %s com %d regras\n", filename, len(e.Rules))
+
+	for _, f := range migrated {
+		os.Rename(f, f+".migrated")
+	}
+}
+
+func (e *RuleEngine) addLegacy(src Source, entries []legacyEntry) {
+	for _, le := range entries {
+		e.Rules = append(e.Rules, Rule{
+			Name:    le.Name,
+			Source:  src,
+			Trigger: TriggerAppeared,
+			Types:   []uint32{le.Type},
+			Action:  &Action{Key: le.Use},
+		})
+	}
+}
+
+var defaultCCRules = []legacyEntry{
+	{Type: 3601, Name: "stun", Use: "F12"},
+	{Type: 509, Name: "knockdown", Use: "SHIFT+F12"},
+	{Type: 4622, Name: "sleep", Use: "CTRL+F11"},
+	{Type: 6800, Name: "fear", Use: "F12"},
+	{Type: 20121, Name: "silence", Use: "SHIFT+1"},
+	{Type: 22290, Name: "root", Use: "CTRL+2"},
+}
+
+var defaultBuffRules = []legacyEntry{
+	{Type: 87, Name: "Hell Spear", Use: "F10"},
+	{Type: 243, Name: "stun", Use: "SHIFT+1"},
+	{Type: 156, Name: "Fear", Use: "CTRL+2"},
+	{Type: 21402, Name: "Deafened", Use: "ALT+F1"},
+	{Type: 8000210, Name: "Clash Dummy", Use: "SHIFT+5"},
+	{Type: 21, Name: "Tripped (Strong)", Use: "CTRL+SHIFT+1"},
+	{Type: 141, Name: "Tripped", Use: "9"},
+	{Type: 6860, Name: "Impaled", Use: "SHIFT+F10"},
+	{Type: 18396, Name: "Skewer", Use: "F10"},
+	{Type: 2458, Name: "Snare (charge)", Use: "F11"},
+	{Type: 6829, Name: "Throw Dagger", Use: "CTRL+F11"},
+	{Type: 501, Name: "Shield Slam", Use: "F10"},
+	{Type: 3601, Name: "Overrun", Use: "SHIFT+F12"},
+}
+
+// Count returns the number of rules for src.
+func (e *RuleEngine) Count(src Source) int {
+	n := 0
+	for i := range e.Rules {
+		if e.Rules[i].Source == src {
+			n++
+		}
+	}
+	return n
+}
+
+// Name labels id: the catalog first, then the first rule that lists it.
+func (e *RuleEngine) Name(src Source, id uint32) string {
+	if n, ok := e.Names[src][id]; ok {
+		return n
+	}
+	for i := range e.Rules {
+		r := &e.Rules[i]
+		if r.Source != src {
+			continue
+		}
+		for _, t := range r.Types {
+			if t == id {
+				return r.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Matches reports whether any rule of src covers id, whatever the trigger;
+// the debuff monitor uses it to count the active CCs.
+func (e *RuleEngine) Matches(src Source, id uint32) bool {
+	name := e.Name(src, id)
+	for i := range e.Rules {
+		if e.Rules[i].Source == src && e.Rules[i].matchesEntry(id, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the highest-priority rule for ctx; ties go to the first in
+// the file.
+func (e *RuleEngine) Match(ctx Context) (*Rule, bool) {
+	name := e.Name(ctx.Source, ctx.ID)
+	var best *Rule
+	for i := range e.Rules {
+		r := &e.Rules[i]
+		if r.Source != ctx.Source || r.Trigger != ctx.Trigger {
+			continue
+		}
+		if (best == nil || r.Priority > best.Priority) && r.matchesEntry(ctx.ID, name) && r.Conditions.check(ctx) {
+			best = r
+		}
+	}
+	return best, best != nil
+}
+
+func keyName(combo input.KeyCombo) string {
+	return strings.ToUpper(strings.ReplaceAll(combo.RawString, " ", ""))
+}
+
+// KeyReady reports whether key can be used at now.
+func (e *RuleEngine) KeyReady(combo input.KeyCombo, now time.Time) bool {
+	return !now.Before(e.keyReady[keyName(combo)])
+}
+
+// Aviso de "nenhuma quebra disponível" no máximo uma vez por segundo por
+// regra (expiring é reavaliado a cada tick)
+const noBreakLogInterval = time.Second
+
+// React fires the first action of the matching rule whose key is off
+// cooldown. It returns false when nothing matched or every action of the
+// rule is on cooldown; other keys are not affected.
+func (e *RuleEngine) React(ctx Context) (bool, *Rule, *Action) {
+	r, ok := e.Match(ctx)
+	if !ok {
+		return false, nil, nil
+	}
+
+	now := time.Now()
+	var action *Action
+	for _, a := range r.actions {
+		if e.KeyReady(a.combo, now) {
+			action = a
+			break
+		}
+	}
+	if action == nil {
+		if now.Sub(r.lastNoBreak) >= noBreakLogInterval {
+			r.lastNoBreak = now
+			waits := make([]string, 0, len(r.actions))
+			for _, a := range r.actions {
+				left := e.keyReady[keyName(a.combo)].Sub(now)
+				waits = append(waits, fmt.Sprintf("%s %.1fs", a.Key, left.Seconds()))
+			}
+			fmt.Printf("[RULES] %s: nenhuma quebra disponível (%s)\n", r.Name, strings.Join(waits, ", "))
+		}
+		return false, r, nil
+	}
+
+	e.keyReady[keyName(action.combo)] = now.Add(r.cooldown(action))
+
+	count, interval := e.SpamCount, e.SpamInterval
+	if r.SpamCount > 0 {
+		count = r.SpamCount
+	}
+	if r.SpamIntervalMs > 0 {
+		interval = time.Duration(r.SpamIntervalMs) * time.Millisecond
+	}
+	go input.SpamKey(action.combo.RawString, count, interval)
+
+	return true, r, action
+}
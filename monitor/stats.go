@@ -0,0 +1,220 @@
+package monitor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Uma reação é confirmada quando a entrada que a disparou some da lista
+// dentro de VerifyWindow. Se sumir só quando o tempo dela acabaria de
+// qualquer jeito, conta como expirada, não como sucesso.
+const (
+	VerifyWindow = 3 * time.Second
+	expiryMargin = 300 * time.Millisecond
+	ReportDir    = "reports"
+)
+
+// RuleStats is the verification record of one rule.
+type RuleStats struct {
+	Source    Source
+	Rule      string
+	Reactions int // reações verificáveis (appeared/refreshed)
+	Successes int
+	Failures  int // não sumiu dentro da janela
+	Expired   int // sumiu pelo tempo, não pela reação
+
+	TotalClear time.Duration // soma dos tempos até sumir, nos sucessos
+	MinClear   time.Duration
+	MaxClear   time.Duration
+}
+
+// SuccessRate is successes over decided reactions (naturally expired ones
+// are left out); ok is false while nothing was decided.
+func (s RuleStats) SuccessRate() (float64, bool) {
+	n := s.Successes + s.Failures
+	if n == 0 {
+		return 0, false
+	}
+	return float64(s.Successes) / float64(n), true
+}
+
+func (s RuleStats) AvgClear() time.Duration {
+	if s.Successes == 0 {
+		return 0
+	}
+	return s.TotalClear / time.Duration(s.Successes)
+}
+
+type entryRef struct {
+	source Source
+	key    uint64
+}
+
+type pendingReaction struct {
+	stats   *RuleStats
+	at      time.Time
+	expires time.Time // quando a entrada acabaria sozinha; zero = sem duração
+}
+
+// ReactionStats correlates reactions with the disappearance of the entry
+// that triggered them.
+type ReactionStats struct {
+	mu      sync.Mutex
+	pending map[entryRef]pendingReaction
+	rules   map[string]*RuleStats
+}
+
+func NewReactionStats() *ReactionStats {
+	return &ReactionStats{
+		pending: make(map[entryRef]pendingReaction),
+		rules:   make(map[string]*RuleStats),
+	}
+}
+
+// Reacted records a reaction of rule to the entry key of src. Reactions to
+// expiring entries can't be verified and are ignored.
+func (s *ReactionStats) Reacted(src Source, key uint64, rule *Rule, timeLeft uint32, now time.Time) {
+	if s == nil || rule.Trigger == TriggerExpiring {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := string(src) + "/" + rule.Name
+	rs := s.rules[id]
+	if rs == nil {
+		rs = &RuleStats{Source: src, Rule: rule.Name}
+		s.rules[id] = rs
+	}
+	rs.Reactions++
+
+	// Reagiu de novo na mesma entrada (reaplicada): a anterior não resolveu
+	ref := entryRef{src, key}
+	if p, ok := s.pending[ref]; ok {
+		p.stats.Failures++
+	}
+	pr := pendingReaction{stats: rs, at: now}
+	if timeLeft > 0 {
+		pr.expires = now.Add(time.Duration(timeLeft) * time.Millisecond)
+	}
+	s.pending[ref] = pr
+}
+
+// Cleared is called when the entry key of src leaves the list.
+func (s *ReactionStats) Cleared(src Source, key uint64, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := entryRef{src, key}
+	p, ok := s.pending[ref]
+	if !ok {
+		return
+	}
+	delete(s.pending, ref)
+
+	elapsed := now.Sub(p.at)
+	switch {
+	case elapsed > VerifyWindow:
+		p.stats.Failures++
+	case !p.expires.IsZero() && !now.Before(p.expires.Add(-expiryMargin)):
+		p.stats.Expired++
+	default:
+		rs := p.stats
+		rs.Successes++
+		rs.TotalClear += elapsed
+		if rs.MinClear == 0 || elapsed < rs.MinClear {
+			rs.MinClear = elapsed
+		}
+		if elapsed > rs.MaxClear {
+			rs.MaxClear = elapsed
+		}
+	}
+}
+
+// Forget drops the pending reaction of the entry key of src without counting
+// it, when the list couldn't be read and its fate is unknown.
+func (s *ReactionStats) Forget(src Source, key uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, entryRef{src, key})
+}
+
+// Expire fails the reactions whose entry is still there after VerifyWindow.
+func (s *ReactionStats) Expire(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ref, p := range s.pending {
+		if now.Sub(p.at) > VerifyWindow {
+			delete(s.pending, ref)
+			p.stats.Failures++
+		}
+	}
+}
+
+// Rules returns a copy of the per-rule stats, most used first.
+func (s *ReactionStats) Rules() []RuleStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]RuleStats, 0, len(s.rules))
+	for _, rs := range s.rules {
+		list = append(list, *rs)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Reactions != list[j].Reactions {
+			return list[i].Reactions > list[j].Reactions
+		}
+		return list[i].Rule < list[j].Rule
+	})
+	return list
+}
+
+// WriteReport saves the stats as CSV in ReportDir and returns the file name.
+func (s *ReactionStats) WriteReport(now time.Time) (string, error) {
+	os.MkdirAll(ReportDir, 0755)
+	filename := filepath.Join(ReportDir, "reactions_"+now.Format("20060102_150405")+".csv")
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"source", "rule", "reactions", "successes", "failures", "expired", "success_rate", "avg_clear_ms", "min_clear_ms", "max_clear_ms"})
+	for _, rs := range s.Rules() {
+		rate := ""
+		if r, ok := rs.SuccessRate(); ok {
+			rate = fmt.Sprintf("%.3f", r)
+		}
+		w.Write([]string{
+			string(rs.Source), rs.Rule,
+			strconv.Itoa(rs.Reactions), strconv.Itoa(rs.Successes), strconv.Itoa(rs.Failures), strconv.Itoa(rs.Expired),
+			rate,
+			strconv.FormatInt(rs.AvgClear().Milliseconds(), 10),
+			strconv.FormatInt(rs.MinClear.Milliseconds(), 10),
+			strconv.FormatInt(rs.MaxClear.Milliseconds(), 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
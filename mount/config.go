@@ -14,6 +14,11 @@ type MountConfig struct {
 	SkillKey string `json:"skill_key"`
 	Enabled  bool   `json:"enabled"`
 
+	// SkillKey dispara quando o HP da montaria cai abaixo desta fração do
+	// máximo (0 desliga), no máximo uma vez a cada SkillCooldownMs
+	SkillHPBelow    float32 `json:"skill_hp_below"`
+	SkillCooldownMs int     `json:"skill_cooldown_ms"`
+
 	// Estado
 	lastAddr     uint32
 	mutex        sync.RWMutex
@@ -28,6 +33,9 @@ func NewMountConfig() *MountConfig {
 		SkillKey: "LSHIFT+R",
 		Enabled:  true,
 		cooldown: 500 * time.Millisecond,
+
+		SkillHPBelow:    0.5,
+		SkillCooldownMs: 10000,
 	}
 	mc.LoadFromFile("mount_config.json")
 	return mc
@@ -49,7 +57,7 @@ func (mc *MountConfig) LoadFromFile(filename string) error {
 		return err
 	}
 
-	fmt.Printf("[Mount] Config: mount=%s skill=%s enabled=%v\n", mc.MountKey, mc.SkillKey, mc.Enabled)
+	fmt.Printf("[Mount] Config: mount=%s skill=%s (hp < %.0f%%) enabled=%v\n", mc.MountKey, mc.SkillKey, mc.SkillHPBelow*100, mc.Enabled)
 	return nil
 }
 
@@ -61,8 +69,8 @@ func (mc *MountConfig) SaveToFile(filename string) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
-// Update é chamado todo frame
-func (mc *MountConfig) Update(addr uint32, name string) {
+// Update é chamado todo frame com a montaria atual (addr 0 = desmontado)
+func (mc *MountConfig) Update(addr uint32, name string, hp, maxHP uint32) {
 	if !mc.Enabled {
 		return
 	}
@@ -73,10 +81,6 @@ func (mc *MountConfig) Update(addr uint32, name string) {
 	hasMount := addr != 0
 	hadMount := mc.lastAddr != 0
 
-	if hasMount {
-		fmt.Printf("[Mount DEBUG] addr=0x%X name=%s\n", addr, name)
-	}
-
 	if hasMount && !hadMount {
 		if mc.MountKey != "" && time.Since(mc.lastMountKey) >= mc.cooldown {
 			fmt.Printf("[Mount] ★ %s detectada → %s\n", name, mc.MountKey)
@@ -89,6 +93,16 @@ func (mc *MountConfig) Update(addr uint32, name string) {
 		fmt.Printf("[Mount] Desmontou\n")
 	}
 
+	if hasMount && mc.SkillKey != "" && mc.SkillHPBelow > 0 && maxHP > 0 {
+		pct := float32(hp) / float32(maxHP)
+		cooldown := time.Duration(mc.SkillCooldownMs) * time.Millisecond
+		if pct < mc.SkillHPBelow && time.Since(mc.lastSkillKey) >= cooldown {
+			fmt.Printf("[Mount] %s com %.0f%% HP → %s\n", name, pct*100, mc.SkillKey)
+			go input.SendKeyCombo(input.ParseKeyCombo(mc.SkillKey))
+			mc.lastSkillKey = time.Now()
+		}
+	}
+
 	mc.lastAddr = addr
 }
 
@@ -0,0 +1,164 @@
+package offsets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"muletinha/memory"
+	"os"
+	"strings"
+)
+
+// BuildID identifies a build of x2game.dll. The PE header fields come from
+// process memory (so they also work on snapshots); size, hash and version come
+// from the DLL file when its path is known. Empty fields are "unknown".
+type BuildID struct {
+	TimeDateStamp uint32 `json:"timestamp,omitempty"`
+	SizeOfImage   uint32 `json:"size_of_image,omitempty"`
+	FileSize      int64  `json:"file_size,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+	Version       string `json:"version,omitempty"`
+}
+
+func (b BuildID) IsZero() bool {
+	return b == BuildID{}
+}
+
+// Matches reports whether b (from a profile) describes actual: at least one
+// field must be known on both sides and every field known on both must agree.
+func (b BuildID) Matches(actual BuildID) bool {
+	compared := 0
+	check := func(known bool, equal bool) bool {
+		if !known {
+			return true
+		}
+		compared++
+		return equal
+	}
+
+	ok := check(b.TimeDateStamp != 0 && actual.TimeDateStamp != 0, b.TimeDateStamp == actual.TimeDateStamp) &&
+		check(b.SizeOfImage != 0 && actual.SizeOfImage != 0, b.SizeOfImage == actual.SizeOfImage) &&
+		check(b.FileSize != 0 && actual.FileSize != 0, b.FileSize == actual.FileSize) &&
+		check(b.SHA256 != "" && actual.SHA256 != "", strings.EqualFold(b.SHA256, actual.SHA256)) &&
+		check(b.Version != "" && actual.Version != "", b.Version == actual.Version)
+
+	return ok && compared > 0
+}
+
+func (b BuildID) String() string {
+	parts := make([]string, 0, 4)
+	if b.Version != "" {
+		parts = append(parts, "v"+b.Version)
+	}
+	if b.TimeDateStamp != 0 {
+		parts = append(parts, fmt.Sprintf("ts=%08X", b.TimeDateStamp))
+	}
+	if b.FileSize != 0 {
+		parts = append(parts, fmt.Sprintf("size=%d", b.FileSize))
+	}
+	if b.SHA256 != "" {
+		parts = append(parts, "sha256="+b.shortHash())
+	}
+	if len(parts) == 0 {
+		return "(desconhecida)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Short is a file-name friendly identifier.
+func (b BuildID) Short() string {
+	switch {
+	case b.Version != "":
+		return b.Version
+	case b.TimeDateStamp != 0:
+		return fmt.Sprintf("%08X", b.TimeDateStamp)
+	case b.SHA256 != "":
+		return b.shortHash()
+	}
+	return "unknown"
+}
+
+// shortHash is the start of SHA256; a hand-edited hash may be shorter.
+func (b BuildID) shortHash() string {
+	if len(b.SHA256) < 12 {
+		return b.SHA256
+	}
+	return b.SHA256[:12]
+}
+
+// ReadBuildID reads TimeDateStamp and SizeOfImage from the PE header mapped
+// at base.
+func ReadBuildID(pm memory.ProcessMemory, base uintptr) (BuildID, error) {
+	var dos [0x40]byte
+	if err := memory.ReadMemoryBytes(pm, base, dos[:]); err != nil {
+		return BuildID{}, err
+	}
+	if dos[0] != 'M' || dos[1] != 'Z' {
+		return BuildID{}, fmt.Errorf("no MZ header at %08X", base)
+	}
+
+	ntOff := binary.LittleEndian.Uint32(dos[0x3C:])
+	var nt [0x60]byte
+	if err := memory.ReadMemoryBytes(pm, base+uintptr(ntOff), nt[:]); err != nil {
+		return BuildID{}, err
+	}
+	if !bytes.Equal(nt[:4], []byte("PE\x00\x00")) {
+		return BuildID{}, fmt.Errorf("no PE header at %08X", base+uintptr(ntOff))
+	}
+
+	// IMAGE_FILE_HEADER.TimeDateStamp, IMAGE_OPTIONAL_HEADER.SizeOfImage
+	return BuildID{
+		TimeDateStamp: binary.LittleEndian.Uint32(nt[8:]),
+		SizeOfImage:   binary.LittleEndian.Uint32(nt[24+56:]),
+	}, nil
+}
+
+// ReadFileBuildID hashes the DLL on disk and extracts its file version.
+func ReadFileBuildID(path string) (BuildID, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BuildID{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	return BuildID{
+		FileSize: int64(len(data)),
+		SHA256:   hex.EncodeToString(sum[:]),
+		Version:  peFileVersion(data),
+	}, nil
+}
+
+// peFileVersion finds VS_FIXEDFILEINFO (signature 0xFEEF04BD) in the
+// resources and formats its FileVersion.
+func peFileVersion(data []byte) string {
+	sig := []byte{0xBD, 0x04, 0xEF, 0xFE}
+	i := bytes.Index(data, sig)
+	if i < 0 || i+16 > len(data) {
+		return ""
+	}
+	ms := binary.LittleEndian.Uint32(data[i+8:])
+	ls := binary.LittleEndian.Uint32(data[i+12:])
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xFFFF, ls>>16, ls&0xFFFF)
+}
+
+// DetectBuild combines what memory and the file on disk can tell.
+func DetectBuild(pm memory.ProcessMemory, base uintptr, path string) BuildID {
+	build, err := ReadBuildID(pm, base)
+	if err != nil {
+		fmt.Printf("[OFFSETS] Header PE ilegível: %v\n", err)
+	}
+
+	if path != "" {
+		fileBuild, err := ReadFileBuildID(path)
+		if err != nil {
+			fmt.Printf("[OFFSETS] Não foi possível ler %s: %v\n", path, err)
+		} else {
+			build.FileSize = fileBuild.FileSize
+			build.SHA256 = fileBuild.SHA256
+			build.Version = fileBuild.Version
+		}
+	}
+	return build
+}
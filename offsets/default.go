@@ -0,0 +1,71 @@
+package offsets
+
+import (
+	"muletinha/config"
+	"muletinha/memory"
+)
+
+// DefaultBuild is the x2game.dll build the offsets compiled into config were
+// found for.
+var DefaultBuild = BuildID{
+	TimeDateStamp: config.X2GAME_TIMESTAMP,
+	SizeOfImage:   config.X2GAME_SIZE_OF_IMAGE,
+}
+
+// Default builds a profile from the offsets compiled into config, for
+// DefaultBuild. It is only used to seed the profile directory when the client
+// is that build or when the offsets check out against it.
+func Default() *Profile {
+	p := &Profile{
+		Name: "default",
+		Chains: Chains{
+			LocalPlayer: config.ChainLocalPlayer,
+			Mana:        config.ChainMana,
+			DebuffList:  config.ChainDebuffList,
+			BuffFreeze:  config.ChainBuffFreeze,
+			Mount:       config.ChainMount,
+			MaxHP:       config.ChainMaxHP,
+			EntityName:  config.ChainEntityName,
+			Target:      memory.PointerChain{Module: "x2game.dll"},
+		},
+		Entity: EntityOffsets{
+			PosX: config.OFF_POS_X,
+			PosZ: config.OFF_POS_Z,
+			PosY: config.OFF_POS_Y,
+			HP:   config.OFF_HP_ENTITY,
+		},
+		Mana: ManaOffsets{
+			Current: config.OFF_MANA_CURRENT,
+			Max:     config.OFF_MANA_MAX,
+		},
+		Buff: BuffOffsets{
+			Count:    config.BUFF_COUNT_OFF,
+			Array:    config.BUFF_ARRAY_OFF,
+			Size:     config.BUFF_SIZE,
+			Slot:     config.BUFF_OFF_SLOT,
+			ID:       config.BUFF_OFF_ID,
+			Duration: config.BUFF_OFF_DUR,
+			TimeLeft: config.BUFF_OFF_LEFT,
+		},
+		Debuff: DebuffOffsets{
+			Count:    config.OFF_DEBUFF_COUNT,
+			Array:    config.OFF_DEBUFF_ARRAY,
+			Size:     config.DEBUFF_SIZE,
+			ID:       0x00,
+			TypeID:   0x04,
+			Duration: 0x30,
+			TimeLeft: 0x34,
+		},
+		Target: TargetOffsets{
+			MaxHP:   config.OFF_TARGET_MAXHP,
+			HP:      config.OFF_TARGET_HP,
+			MaxMana: config.OFF_TARGET_MAXMANA,
+			Mana:    config.OFF_TARGET_MANA,
+			ID:      config.OFF_TARGET_ID,
+			Type:    config.OFF_TARGET_TYPE,
+			Level:   config.OFF_TARGET_LEVEL,
+		},
+	}
+	p.fillChainNames()
+	return p
+}
@@ -0,0 +1,65 @@
+package offsets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"muletinha/memory"
+	"reflect"
+	"strings"
+)
+
+// As structs de offsets são gravadas com os valores em hex ("0x84C"), na
+// ordem dos campos, e aceitam tanto "0x..." quanto números na leitura.
+
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
+
+func marshalHex(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < rt.NumField(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:\"0x%X\"", jsonName(rt.Field(i)), rv.Field(i).Uint())
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func unmarshalHex(data []byte, v any) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name := jsonName(rt.Field(i))
+		msg, ok := raw[name]
+		if !ok {
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(msg, &s); err != nil {
+			s = string(msg) // número puro
+		}
+		val, err := memory.ParseHex(s)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		rv.Field(i).SetUint(uint64(val))
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package offsets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"muletinha/memory"
+	"muletinha/sigscan"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// ProfileDir é onde ficam os perfis de offsets, um JSON por build do cliente.
+const ProfileDir = "profiles"
+
+var ErrUnsupportedBuild = errors.New("unsupported build")
+
+// Chains são todas as cadeias de ponteiros usadas pelo overlay.
+type Chains struct {
+	LocalPlayer memory.PointerChain `json:"localplayer"`
+	Mana        memory.PointerChain `json:"mana"`
+	DebuffList  memory.PointerChain `json:"debuff_list"`
+	BuffFreeze  memory.PointerChain `json:"buff_freeze"`
+	Mount       memory.PointerChain `json:"mount"`
+	MaxHP       memory.PointerChain `json:"max_hp"`
+	EntityName  memory.PointerChain `json:"entity_name"`
+
+	// Estrutura de UI do target (TargetOffsets). A base é dinâmica: use uma
+	// cadeia ou uma assinatura "target". Sem base o painel de target some.
+	Target memory.PointerChain `json:"target"`
+}
+
+// Optional chains may be left without a base in a profile.
+func OptionalChain(name string) bool {
+	return name == "mount" || name == "target"
+}
+
+// All returns every chain in a fixed order.
+func (c *Chains) All() []*memory.PointerChain {
+	return []*memory.PointerChain{
+		&c.LocalPlayer, &c.Mana, &c.DebuffList, &c.BuffFreeze,
+		&c.Mount, &c.MaxHP, &c.EntityName, &c.Target,
+	}
+}
+
+// Offsets dentro da struct de entidade
+type EntityOffsets struct {
+	PosX uint32 `json:"pos_x"`
+	PosZ uint32 `json:"pos_z"`
+	PosY uint32 `json:"pos_y"`
+	HP   uint32 `json:"hp"`
+	ID   uint32 `json:"id"` // ID único do jogo; 0 = desconhecido
+
+	// float com a direção em radianos (0 = norte, sentido horário); 0 =
+	// desconhecido e o radar não gira
+	Heading uint32 `json:"heading"`
+
+	// Faixa de RVAs do x2game.dll onde ficam as vtables de entidade
+	// (max exclusivo); 0/0 = imagem inteira
+	VTableMin uint32 `json:"vtable_min"`
+	VTableMax uint32 `json:"vtable_max"`
+}
+
+// Offsets relativos ao objeto retornado pela cadeia de mana
+type ManaOffsets struct {
+	Current uint32 `json:"current"`
+	Max     uint32 `json:"max"`
+}
+
+// Lista de buffs: count/array relativos à lista, o resto relativo a cada entrada
+type BuffOffsets struct {
+	Count    uint32 `json:"count"`
+	Array    uint32 `json:"array"`
+	Size     uint32 `json:"size"`
+	Slot     uint32 `json:"slot"`
+	ID       uint32 `json:"id"`
+	Duration uint32 `json:"duration"`
+	TimeLeft uint32 `json:"time_left"`
+	Stack    uint32 `json:"stack"` // acúmulos; 0 = desconhecido
+}
+
+type DebuffOffsets struct {
+	Count    uint32 `json:"count"`
+	Array    uint32 `json:"array"`
+	Size     uint32 `json:"size"`
+	ID       uint32 `json:"id"`
+	TypeID   uint32 `json:"type_id"`
+	Duration uint32 `json:"duration"`
+	TimeLeft uint32 `json:"time_left"`
+	Stack    uint32 `json:"stack"` // acúmulos; 0 = desconhecido
+}
+
+// Estrutura de UI do target
+type TargetOffsets struct {
+	MaxHP   uint32 `json:"max_hp"`
+	HP      uint32 `json:"hp"`
+	MaxMana uint32 `json:"max_mana"`
+	Mana    uint32 `json:"mana"`
+	ID      uint32 `json:"id"`
+	Type    uint32 `json:"type"`
+	Level   uint32 `json:"level"`
+}
+
+func (o EntityOffsets) MarshalJSON() ([]byte, error)     { return marshalHex(o) }
+func (o *EntityOffsets) UnmarshalJSON(data []byte) error { return unmarshalHex(data, o) }
+func (o ManaOffsets) MarshalJSON() ([]byte, error)       { return marshalHex(o) }
+func (o *ManaOffsets) UnmarshalJSON(data []byte) error   { return unmarshalHex(data, o) }
+func (o BuffOffsets) MarshalJSON() ([]byte, error)       { return marshalHex(o) }
+func (o *BuffOffsets) UnmarshalJSON(data []byte) error   { return unmarshalHex(data, o) }
+func (o DebuffOffsets) MarshalJSON() ([]byte, error)     { return marshalHex(o) }
+func (o *DebuffOffsets) UnmarshalJSON(data []byte) error { return unmarshalHex(data, o) }
+func (o TargetOffsets) MarshalJSON() ([]byte, error)     { return marshalHex(o) }
+func (o *TargetOffsets) UnmarshalJSON(data []byte) error { return unmarshalHex(data, o) }
+
+// Profile é o conjunto completo de offsets de uma build do x2game.dll.
+type Profile struct {
+	Name   string        `json:"name"`
+	Build  BuildID       `json:"build"`
+	Chains Chains        `json:"chains"`
+	Entity EntityOffsets `json:"entity"`
+	Mana   ManaOffsets   `json:"mana"`
+	Buff   BuffOffsets   `json:"buff"`
+	Debuff DebuffOffsets `json:"debuff"`
+	Target TargetOffsets `json:"target"`
+
+	// Codificação dos textos do jogo (nomes): "utf8" (padrão) ou "utf16"
+	Encoding memory.Encoding `json:"encoding,omitempty"`
+
+	// Assinaturas por nome de cadeia: quando presentes, a base da cadeia é
+	// encontrada no código do x2game.dll em vez de vir fixa no perfil.
+	Signatures map[string]sigscan.Signature `json:"signatures,omitempty"`
+
+	File string `json:"-"`
+}
+
+func (p *Profile) fillChainNames() {
+	names := []string{"localplayer", "mana", "debuff_list", "buff_freeze", "mount", "max_hp", "entity_name", "target"}
+	for i, c := range p.Chains.All() {
+		c.Name = names[i]
+	}
+}
+
+// Validate rejects profiles that would make the readers walk garbage.
+func (p *Profile) Validate() error {
+	if p.Build.IsZero() {
+		return fmt.Errorf("build vazio")
+	}
+	for _, c := range p.Chains.All() {
+		if OptionalChain(c.Name) {
+			continue
+		}
+		if _, ok := p.Signatures[c.Name]; ok {
+			continue
+		}
+		if c.Base == 0 {
+			return fmt.Errorf("cadeia %s sem base", c.Name)
+		}
+	}
+	if p.Buff.Size == 0 || p.Debuff.Size == 0 {
+		return fmt.Errorf("tamanho de buff/debuff zerado")
+	}
+	for _, off := range []uint32{p.Buff.Slot, p.Buff.ID, p.Buff.Duration, p.Buff.TimeLeft, p.Buff.Stack} {
+		if off+4 > p.Buff.Size {
+			return fmt.Errorf("campo de buff 0x%X fora da entrada (0x%X)", off, p.Buff.Size)
+		}
+	}
+	for _, off := range []uint32{p.Debuff.ID, p.Debuff.TypeID, p.Debuff.Duration, p.Debuff.TimeLeft, p.Debuff.Stack} {
+		if off+4 > p.Debuff.Size {
+			return fmt.Errorf("campo de debuff 0x%X fora da entrada (0x%X)", off, p.Debuff.Size)
+		}
+	}
+	if p.Entity.HP == 0 || p.Entity.PosX == 0 {
+		return fmt.Errorf("offsets de entidade zerados")
+	}
+	if p.Entity.VTableMin > p.Entity.VTableMax {
+		return fmt.Errorf("vtable_min 0x%X > vtable_max 0x%X", p.Entity.VTableMin, p.Entity.VTableMax)
+	}
+	if _, err := memory.ParseEncoding(string(p.Encoding)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ApplySignatures resolves the profile's signatures against the x2game.dll
+// code and overwrites the base of the corresponding chains.
+func (p *Profile) ApplySignatures(mod *sigscan.Module) error {
+	for _, c := range p.Chains.All() {
+		sig, ok := p.Signatures[c.Name]
+		if !ok {
+			continue
+		}
+		rva, err := sig.Resolve(mod)
+		if err != nil {
+			return fmt.Errorf("assinatura %s: %w", c.Name, err)
+		}
+		if c.Base != 0 && c.Base != rva {
+			fmt.Printf("[OFFSETS] %s: base 0x%X do perfil substituída por 0x%X (assinatura)\n", c.Name, c.Base, rva)
+		}
+		c.Base = rva
+	}
+	return nil
+}
+
+func LoadProfile(filename string) (*Profile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	p.fillChainNames()
+	p.File = filename
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return &p, nil
+}
+
+func (p *Profile) SaveToFile(filename string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// LoadProfiles loads every *.json in dir, skipping (and reporting) broken ones.
+func LoadProfiles(dir string) ([]*Profile, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var profiles []*Profile
+	for _, f := range files {
+		p, err := LoadProfile(f)
+		if err != nil {
+			fmt.Printf("[OFFSETS] Perfil ignorado: %v\n", err)
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// Select picks the profile for build. When none matches and build is
+// DefaultBuild, the compiled-in defaults are written as its profile; any
+// other build is unsupported (diagnose.SelectProfile can still adopt the
+// defaults after checking them against the client).
+func Select(dir string, build BuildID) (*Profile, error) {
+	profiles, err := LoadProfiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range profiles {
+		if p.Build.Matches(build) {
+			return p, nil
+		}
+	}
+
+	if DefaultBuild.Matches(build) {
+		return SaveDefault(dir, build)
+	}
+	return nil, fmt.Errorf("%w: x2game.dll %s (%d perfis em %s)", ErrUnsupportedBuild, build, len(profiles), dir)
+}
+
+// SaveDefault writes the compiled-in defaults as the profile of build, so the
+// next attach finds it by build like any other profile.
+func SaveDefault(dir string, build BuildID) (*Profile, error) {
+	if build.IsZero() {
+		return nil, fmt.Errorf("build desconhecida, perfil padrão não salvo")
+	}
+
+	p := Default()
+	p.Build = build
+	p.Name = "default-" + build.Short()
+
+	os.MkdirAll(dir, 0755)
+	filename := filepath.Join(dir, p.Name+".json")
+	if err := p.SaveToFile(filename); err != nil {
+		return nil, err
+	}
+	p.File = filename
+	fmt.Printf("[OFFSETS] Nenhum perfil encontrado, criado %s para a build %s\n", filename, build)
+	return p, nil
+}
+
+var active atomic.Pointer[Profile]
+
+// Active returns the profile selected at attach time, or nil.
+func Active() *Profile {
+	return active.Load()
+}
+
+func SetActive(p *Profile) {
+	active.Store(p)
+}
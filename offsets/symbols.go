@@ -0,0 +1,49 @@
+package offsets
+
+import (
+	"muletinha/memory"
+	"reflect"
+	"strings"
+)
+
+// Profile implements memory.Symbols, so structs can tag fields with the
+// profile's names: `mem:"entity.hp"` (section.field, as in the JSON) or
+// `mem:"@max_hp"` (a chain).
+
+func (p *Profile) Offset(name string) (uint32, bool) {
+	section, field, ok := strings.Cut(name, ".")
+	if !ok {
+		return 0, false
+	}
+
+	rv := reflect.ValueOf(p).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if jsonName(sf) != section || !strings.HasSuffix(sf.Type.Name(), "Offsets") {
+			continue
+		}
+
+		sec := rv.Field(i)
+		for j := 0; j < sec.NumField(); j++ {
+			if jsonName(sec.Type().Field(j)) == field {
+				return uint32(sec.Field(j).Uint()), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (p *Profile) Chain(name string) (*memory.PointerChain, bool) {
+	for _, c := range p.Chains.All() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// StringEncoding makes string fields in mem tags use the profile's encoding.
+func (p *Profile) StringEncoding() memory.Encoding {
+	return p.Encoding
+}
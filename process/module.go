@@ -0,0 +1,17 @@
+package process
+
+// Module describes a loaded module of the target process.
+type Module struct {
+	Name string
+	Base uintptr
+	Size uint32
+	Path string // caminho do arquivo no disco, quando disponível
+}
+
+func GetModuleBase(pid uint32, name string) (uintptr, error) {
+	m, err := GetModule(pid, name)
+	if err != nil {
+		return 0, err
+	}
+	return m.Base, nil
+}
@@ -1,3 +1,5 @@
+//go:build windows
+
 package process
 
 import (
@@ -26,7 +28,7 @@ func FindProcess(name string) (uint32, error) {
 	return 0, fmt.Errorf("not found")
 }
 
-func GetModuleBase(pid uint32, name string) (uintptr, error) {
+func GetModule(pid uint32, name string) (Module, error) {
 	snap, _ := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPMODULE|windows.TH32CS_SNAPMODULE32, pid)
 	defer windows.CloseHandle(snap)
 
@@ -36,11 +38,16 @@ func GetModuleBase(pid uint32, name string) (uintptr, error) {
 
 	for {
 		if windows.UTF16ToString(me.Module[:]) == name {
-			return uintptr(me.ModBaseAddr), nil
+			return Module{
+				Name: name,
+				Base: uintptr(me.ModBaseAddr),
+				Size: me.ModBaseSize,
+				Path: windows.UTF16ToString(me.ExePath[:]),
+			}, nil
 		}
 		if windows.Module32Next(snap, &me) != nil {
 			break
 		}
 	}
-	return 0, fmt.Errorf("not found")
+	return Module{}, fmt.Errorf("not found")
 }
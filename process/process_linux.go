@@ -0,0 +1,84 @@
+//go:build linux
+
+package process
+
+import (
+	"fmt"
+	"muletinha/memory"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FindProcess looks for a Wine/Proton process by scanning /proc. Wine sets
+// comm to the Windows executable name; cmdline carries the full Windows or
+// Unix path, so both are checked.
+func FindProcess(name string) (uint32, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range entries {
+		pid, err := strconv.ParseUint(e.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+
+		if comm, err := os.ReadFile("/proc/" + e.Name() + "/comm"); err == nil {
+			if strings.EqualFold(strings.TrimSpace(string(comm)), name) {
+				return uint32(pid), nil
+			}
+		}
+
+		cmdline, err := os.ReadFile("/proc/" + e.Name() + "/cmdline")
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		argv0 := strings.SplitN(string(cmdline), "\x00", 2)[0]
+		if strings.EqualFold(baseName(argv0), name) {
+			return uint32(pid), nil
+		}
+	}
+	return 0, fmt.Errorf("not found")
+}
+
+// GetModule finds a module in /proc/<pid>/maps. Wine maps the PE image from
+// the DLL file, so its lowest mapping is the module base and the mappings of
+// the same file span the image.
+func GetModule(pid uint32, name string) (Module, error) {
+	maps, err := memory.ProcMaps(int(pid))
+	if err != nil {
+		return Module{}, err
+	}
+
+	var mod Module
+	var end uintptr
+	for _, m := range maps {
+		if m.Path == "" || !strings.EqualFold(baseName(m.Path), name) {
+			continue
+		}
+		if mod.Base == 0 || m.Base < mod.Base {
+			mod.Base = m.Base
+		}
+		if m.End() > end {
+			end = m.End()
+		}
+		mod.Path = m.Path
+	}
+	if mod.Base == 0 {
+		return Module{}, fmt.Errorf("not found")
+	}
+
+	mod.Name = name
+	mod.Size = uint32(end - mod.Base)
+	return mod, nil
+}
+
+// baseName strips both Unix and Windows directory separators.
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
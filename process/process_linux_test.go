@@ -0,0 +1,87 @@
+//go:build linux
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBaseName(t *testing.T) {
+	tests := map[string]string{
+		`C:\Program Files\ArcheAge\bin32\x2game.dll`: "x2game.dll",
+		"/home/u/.wine/drive_c/game/archeage.exe":    "archeage.exe",
+		`Z:\home/u\mixed/x2game.dll`:                 "x2game.dll",
+		"archeage.exe":                               "archeage.exe",
+	}
+	for path, want := range tests {
+		if got := baseName(path); got != want {
+			t.Errorf("baseName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestGetModule finds the test binary in its own /proc/pid/maps, the way
+// x2game.dll is found in a Wine process.
+func TestGetModule(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := strings.ToUpper(filepath.Base(exe)) // Windows não diferencia maiúsculas
+
+	mod, err := GetModule(uint32(os.Getpid()), name)
+	if err != nil {
+		t.Fatalf("GetModule(%s): %v", name, err)
+	}
+	if mod.Path != exe || mod.Base == 0 || mod.Size == 0 {
+		t.Fatalf("GetModule = %+v", mod)
+	}
+
+	// O código desta função está dentro do módulo
+	fn := reflect.ValueOf(TestGetModule).Pointer()
+	if fn < mod.Base || fn >= mod.Base+uintptr(mod.Size) {
+		t.Errorf("TestGetModule at %X outside module %X+%X", fn, mod.Base, mod.Size)
+	}
+
+	if _, err := GetModule(uint32(os.Getpid()), "x2game.dll"); err == nil {
+		t.Error("GetModule found a module that isn't mapped")
+	}
+}
+
+// TestFindProcess spawns a helper and looks it up by name.
+func TestFindProcess(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	helper := filepath.Join(t.TempDir(), "mulehelper.exe")
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(helper, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(helper, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("helper não iniciou: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid, err := FindProcess("MULEHELPER.EXE")
+	if err != nil {
+		t.Fatalf("FindProcess: %v", err)
+	}
+	if int(pid) != cmd.Process.Pid {
+		t.Errorf("FindProcess = %d, want %d", pid, cmd.Process.Pid)
+	}
+}
@@ -0,0 +1,179 @@
+package sigscan
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"muletinha/memory"
+	"strings"
+)
+
+const (
+	scnCntCode    = 0x00000020
+	scnMemExecute = 0x20000000
+)
+
+// Section is an executable section of a module, addressed by RVA.
+type Section struct {
+	Name string
+	RVA  uint32
+	Data []byte
+}
+
+// Module holds the code sections of a PE image. Base is where absolute
+// addresses in the code are relative to: the load address for an image read
+// from process memory (relocations already applied) or the preferred
+// ImageBase for a file on disk.
+type Module struct {
+	Base     uintptr
+	Sections []Section
+}
+
+// SectionHeader is one entry of a PE section table.
+type SectionHeader struct {
+	Name            string
+	RVA             uint32
+	Size            uint32 // VirtualSize
+	Characteristics uint32
+}
+
+// Executable reports whether the section holds code.
+func (h SectionHeader) Executable() bool {
+	return h.Characteristics&(scnCntCode|scnMemExecute) != 0
+}
+
+// ReadSectionHeaders reads the section table of the module mapped at base.
+func ReadSectionHeaders(pm memory.ProcessMemory, base uintptr) ([]SectionHeader, error) {
+	var dos [0x40]byte
+	if err := memory.ReadMemoryBytes(pm, base, dos[:]); err != nil {
+		return nil, err
+	}
+	if dos[0] != 'M' || dos[1] != 'Z' {
+		return nil, fmt.Errorf("no MZ header at %08X", base)
+	}
+
+	ntAddr := base + uintptr(binary.LittleEndian.Uint32(dos[0x3C:]))
+	var nt [24]byte
+	if err := memory.ReadMemoryBytes(pm, ntAddr, nt[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(nt[:4], []byte("PE\x00\x00")) {
+		return nil, fmt.Errorf("no PE header at %08X", ntAddr)
+	}
+
+	// IMAGE_FILE_HEADER.NumberOfSections / SizeOfOptionalHeader
+	numSections := int(binary.LittleEndian.Uint16(nt[6:]))
+	optSize := uintptr(binary.LittleEndian.Uint16(nt[20:]))
+
+	raw := make([]byte, numSections*40)
+	if err := memory.ReadMemoryBytes(pm, ntAddr+24+optSize, raw); err != nil {
+		return nil, err
+	}
+
+	headers := make([]SectionHeader, numSections)
+	for i := range headers {
+		h := raw[i*40 : (i+1)*40]
+		headers[i] = SectionHeader{
+			Name:            strings.TrimRight(string(h[:8]), "\x00"),
+			Size:            binary.LittleEndian.Uint32(h[8:]),
+			RVA:             binary.LittleEndian.Uint32(h[12:]),
+			Characteristics: binary.LittleEndian.Uint32(h[36:]),
+		}
+	}
+	return headers, nil
+}
+
+// ReadModule reads the code sections of the module mapped at base. It works
+// on any backend, including snapshots that recorded the module's code.
+func ReadModule(pm memory.ProcessMemory, base uintptr) (*Module, error) {
+	headers, err := ReadSectionHeaders(pm, base)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Module{Base: base}
+	for _, h := range headers {
+		if !h.Executable() {
+			continue
+		}
+		data := make([]byte, h.Size)
+		if err := memory.ReadMemoryBytes(pm, base+uintptr(h.RVA), data); err != nil {
+			return nil, fmt.Errorf("section %s: %v", h.Name, err)
+		}
+		m.Sections = append(m.Sections, Section{Name: h.Name, RVA: h.RVA, Data: data})
+	}
+
+	if len(m.Sections) == 0 {
+		return nil, fmt.Errorf("no code sections at %08X", base)
+	}
+	return m, nil
+}
+
+// ReadFile loads the code sections of a DLL on disk.
+func ReadFile(path string) (*Module, error) {
+	f, err := pe.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Module{}
+	switch opt := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		m.Base = uintptr(opt.ImageBase)
+	case *pe.OptionalHeader64:
+		m.Base = uintptr(opt.ImageBase)
+	}
+
+	for _, s := range f.Sections {
+		if s.Characteristics&(scnCntCode|scnMemExecute) == 0 {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %v", s.Name, err)
+		}
+		// Raw data is padded to FileAlignment; only VirtualSize is mapped
+		if s.VirtualSize != 0 && int(s.VirtualSize) < len(data) {
+			data = data[:s.VirtualSize]
+		}
+		m.Sections = append(m.Sections, Section{Name: s.Name, RVA: s.VirtualAddress, Data: data})
+	}
+
+	if len(m.Sections) == 0 {
+		return nil, fmt.Errorf("%s: no code sections", path)
+	}
+	return m, nil
+}
+
+// Match is a pattern hit inside a code section.
+type Match struct {
+	Section *Section
+	Offset  int // offset into Section.Data
+}
+
+// RVA of the first byte of the match.
+func (m Match) RVA() uint32 {
+	return m.Section.RVA + uint32(m.Offset)
+}
+
+func (m Match) u32(at uint32) (uint32, error) {
+	i := m.Offset + int(at)
+	if i < 0 || i+4 > len(m.Section.Data) {
+		return 0, fmt.Errorf("operando fora da seção %s", m.Section.Name)
+	}
+	return binary.LittleEndian.Uint32(m.Section.Data[i:]), nil
+}
+
+// Find returns every match of p in the module's code sections.
+func (mod *Module) Find(p Pattern) []Match {
+	var matches []Match
+	for i := range mod.Sections {
+		s := &mod.Sections[i]
+		for _, off := range p.FindAll(s.Data) {
+			matches = append(matches, Match{Section: s, Offset: off})
+		}
+	}
+	return matches
+}
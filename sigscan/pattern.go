@@ -0,0 +1,79 @@
+package sigscan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pattern is an AOB (array of bytes) signature in Cheat Engine notation:
+//
+//	"8B 0D ?? ?? ?? ?? 85 C9 74 ?"
+//
+// "??" or "?" matches any byte.
+type Pattern struct {
+	Bytes []byte
+	Mask  []bool // true = byte must match
+}
+
+func ParsePattern(s string) (Pattern, error) {
+	var p Pattern
+	for _, tok := range strings.Fields(s) {
+		if tok == "?" || tok == "??" {
+			p.Bytes = append(p.Bytes, 0)
+			p.Mask = append(p.Mask, false)
+			continue
+		}
+		b, err := strconv.ParseUint(tok, 16, 8)
+		if err != nil {
+			return Pattern{}, fmt.Errorf("byte inválido %q", tok)
+		}
+		p.Bytes = append(p.Bytes, byte(b))
+		p.Mask = append(p.Mask, true)
+	}
+	if len(p.Bytes) == 0 {
+		return Pattern{}, fmt.Errorf("padrão vazio")
+	}
+	if !p.Mask[0] {
+		return Pattern{}, fmt.Errorf("padrão não pode começar com wildcard")
+	}
+	return p, nil
+}
+
+func (p Pattern) Len() int {
+	return len(p.Bytes)
+}
+
+func (p Pattern) String() string {
+	parts := make([]string, len(p.Bytes))
+	for i, b := range p.Bytes {
+		if p.Mask[i] {
+			parts[i] = fmt.Sprintf("%02X", b)
+		} else {
+			parts[i] = "??"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p Pattern) matchAt(data []byte, i int) bool {
+	for j, b := range p.Bytes {
+		if p.Mask[j] && data[i+j] != b {
+			return false
+		}
+	}
+	return true
+}
+
+// FindAll returns the offsets of every match in data.
+func (p Pattern) FindAll(data []byte) []int {
+	var hits []int
+	first := p.Bytes[0]
+	last := len(data) - len(p.Bytes)
+	for i := 0; i <= last; i++ {
+		if data[i] == first && p.matchAt(data, i) {
+			hits = append(hits, i)
+		}
+	}
+	return hits
+}
@@ -0,0 +1,70 @@
+package sigscan
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound  = errors.New("signature not found")
+	ErrAmbiguous = errors.New("signature matches more than once")
+)
+
+// Signature locates an address referenced by code. Example, for
+// "mov ecx,[x2game.dll+E9DC54]" (8B 0D <abs32>):
+//
+//	{"pattern": "8B 0D ?? ?? ?? ?? 85 C9 74 ?? 8B 41 10", "offset": 2, "type": "abs"}
+//
+// Type "abs" reads an absolute address at Offset; "rip" reads a rel32 at
+// Offset, relative to the end of the instruction (InsnEnd from the start of
+// the match, default Offset+4). Add is applied to the result.
+type Signature struct {
+	Pattern string `json:"pattern"`
+	Offset  uint32 `json:"offset"`
+	Type    string `json:"type"`
+	InsnEnd uint32 `json:"insn_end,omitempty"`
+	Add     int32  `json:"add,omitempty"`
+}
+
+// Resolve finds the signature in mod and returns the referenced address as
+// an RVA (relative to the module base, like PointerChain.Base). The pattern
+// must match exactly once.
+func (s Signature) Resolve(mod *Module) (uintptr, error) {
+	p, err := ParsePattern(s.Pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	matches := mod.Find(p)
+	switch {
+	case len(matches) == 0:
+		return 0, ErrNotFound
+	case len(matches) > 1:
+		return 0, fmt.Errorf("%w (%d vezes)", ErrAmbiguous, len(matches))
+	}
+	m := matches[0]
+
+	v, err := m.u32(s.Offset)
+	if err != nil {
+		return 0, err
+	}
+
+	var rva uint32
+	switch s.Type {
+	case "", "abs":
+		if uintptr(v) < mod.Base {
+			return 0, fmt.Errorf("endereço %08X abaixo da base %08X", v, mod.Base)
+		}
+		rva = v - uint32(mod.Base)
+	case "rip":
+		end := s.InsnEnd
+		if end == 0 {
+			end = s.Offset + 4
+		}
+		rva = m.RVA() + end + v // rel32 com sinal, soma em complemento de dois
+	default:
+		return 0, fmt.Errorf("tipo desconhecido %q", s.Type)
+	}
+
+	return uintptr(rva + uint32(s.Add)), nil
+}
@@ -0,0 +1,185 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"muletinha/memory"
+	"os"
+	"strings"
+	"time"
+)
+
+// Arquivo .snap: magic + gzip(gob(File)). O gob tolera campos novos/removidos,
+// então snapshots antigos continuam abrindo quando o formato cresce.
+const (
+	magic   = "MULESNAP"
+	Version = 2
+)
+
+type Module struct {
+	Name string
+	Base uintptr
+	Size uint32
+}
+
+// Block is a contiguous range of captured memory.
+type Block struct {
+	Base       uintptr
+	Data       []byte
+	CapturedAt time.Time
+}
+
+type File struct {
+	Version    int
+	Process    string
+	CapturedAt time.Time
+	Modules    []Module
+	Blocks     []Block
+	Regions    []memory.Region // mapa do processo na captura (v2+)
+}
+
+// FromRecorder builds a snapshot from the pages a recorder has seen, merging
+// adjacent pages into blocks.
+func FromRecorder(rec *memory.Recorder, process string, modules []Module) *File {
+	f := &File{
+		Version:    Version,
+		Process:    process,
+		CapturedAt: time.Now(),
+		Modules:    modules,
+		Regions:    rec.RecordedRegions(),
+	}
+
+	for _, p := range rec.Pages() {
+		if n := len(f.Blocks); n > 0 {
+			last := &f.Blocks[n-1]
+			if last.Base+uintptr(len(last.Data)) == p.Base {
+				last.Data = append(last.Data, p.Data...)
+				continue
+			}
+		}
+		f.Blocks = append(f.Blocks, Block{
+			Base:       p.Base,
+			Data:       append([]byte(nil), p.Data...),
+			CapturedAt: p.CapturedAt,
+		})
+	}
+	return f
+}
+
+// Size returns the number of captured bytes.
+func (f *File) Size() int {
+	total := 0
+	for _, b := range f.Blocks {
+		total += len(b.Data)
+	}
+	return total
+}
+
+func (f *File) Save(filename string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	if _, err := w.WriteString(magic); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(f); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func Load(filename string) (*File, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	r := bufio.NewReader(in)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, header); err != nil || string(header) != magic {
+		return nil, fmt.Errorf("%s: not a snapshot file", filename)
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var f File
+	if err := gob.NewDecoder(zr).Decode(&f); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if f.Version > Version {
+		return nil, fmt.Errorf("%s: snapshot version %d is newer than supported (%d)", filename, f.Version, Version)
+	}
+	return &f, nil
+}
+
+// Snapshot is a read-only memory backend over a loaded snapshot file.
+type Snapshot struct {
+	*memory.Image
+	File *File
+}
+
+func Open(filename string) (*Snapshot, error) {
+	f, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	img := memory.NewImage()
+	for _, b := range f.Blocks {
+		img.Map(b.Base, b.Data)
+	}
+	return &Snapshot{Image: img, File: f}, nil
+}
+
+// WriteBytes refuses writes so replays never mutate the recorded state.
+func (s *Snapshot) WriteBytes(addr uintptr, data []byte) error {
+	return fmt.Errorf("snapshot is read-only")
+}
+
+// Regions returns the process map recorded at capture time; older snapshots
+// only know their captured blocks.
+func (s *Snapshot) Regions() ([]memory.Region, error) {
+	if len(s.File.Regions) > 0 {
+		return s.File.Regions, nil
+	}
+	return s.Image.Regions()
+}
+
+func (s *Snapshot) QueryRegion(addr uintptr) (memory.Region, error) {
+	for _, r := range s.File.Regions {
+		if r.Contains(addr) {
+			return r, nil
+		}
+	}
+	return s.Image.QueryRegion(addr)
+}
+
+// ModuleBase returns the recorded base of a module.
+func (s *Snapshot) ModuleBase(name string) (uintptr, error) {
+	for _, m := range s.File.Modules {
+		if strings.EqualFold(m.Name, name) {
+			return m.Base, nil
+		}
+	}
+	return 0, fmt.Errorf("module %s not in snapshot", name)
+}
@@ -21,9 +21,11 @@ func DrawCircle(screen *ebiten.Image, cx, cy, radius float32, clr color.RGBA) {
 	}
 }
 
+// TruncStr cuts s to maxLen characters (not bytes, names may be UTF-8).
 func TruncStr(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	r := []rune(s)
+	if len(r) <= maxLen {
 		return s
 	}
-	return s[:maxLen-1] + "."
+	return string(r[:maxLen-1]) + "."
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"muletinha/config"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// A fonte bitmap do DebugPrintAt só tem ASCII. Textos com outros caracteres
+// (nomes em coreano, cirílico, português acentuado) são desenhados com a
+// primeira fonte de config.UI_FONTS que tenha todos os glifos.
+
+const fontSize = 12
+
+var (
+	fontMu    sync.Mutex
+	fontFaces = map[string]font.Face{} // por caminho; nil = não abriu
+	faceCache = map[string]font.Face{}
+	noFonts   bool // aviso de nenhuma fonte já dado
+)
+
+// faceAt returns the face of the font at path, loading it on first use.
+// Coleções CJK têm vários MB, então só são abertas quando um texto precisa.
+func faceAt(path string) font.Face {
+	if face, ok := fontFaces[path]; ok {
+		return face
+	}
+	face, err := loadFace(path)
+	if err == nil {
+		fmt.Printf("[UI] Fonte carregada: %s\n", path)
+	}
+	fontFaces[path] = face
+	return face
+}
+
+func loadFace(path string) (font.Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *sfnt.Font
+	if strings.HasSuffix(strings.ToLower(path), ".ttc") {
+		coll, err := opentype.ParseCollection(data)
+		if err != nil {
+			return nil, err
+		}
+		f, err = coll.Font(0)
+		if err != nil {
+			return nil, err
+		}
+	} else if f, err = opentype.Parse(data); err != nil {
+		return nil, err
+	}
+
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    fontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// faceFor picks the first font of config.UI_FONTS that has every glyph of
+// s, or the first one that loads when none covers it. Fonts after the one
+// that covers s are not opened.
+func faceFor(s string) font.Face {
+	fontMu.Lock()
+	defer fontMu.Unlock()
+
+	if f, ok := faceCache[s]; ok {
+		return f
+	}
+
+	var face, fallback font.Face
+	for _, path := range config.UI_FONTS {
+		f := faceAt(path)
+		if f == nil {
+			continue
+		}
+		if fallback == nil {
+			fallback = f
+		}
+		covered := true
+		for _, r := range s {
+			if _, ok := f.GlyphAdvance(r); !ok {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			face = f
+			break
+		}
+	}
+	if face == nil {
+		face = fallback
+	}
+	if face == nil && !noFonts {
+		noFonts = true
+		fmt.Println("[UI] Nenhuma fonte de config.UI_FONTS encontrada, nomes não-ASCII aparecem com '?'")
+	}
+
+	// Nomes se repetem a cada frame; o cache só é limpo se crescer demais
+	if len(faceCache) > 1000 {
+		faceCache = map[string]font.Face{}
+	}
+	faceCache[s] = face
+	return face
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// DrawText draws s with its top-left corner at (x, y), like
+// ebitenutil.DebugPrintAt, which it uses for plain ASCII.
+func DrawText(screen *ebiten.Image, s string, x, y int) {
+	if isASCII(s) {
+		ebitenutil.DebugPrintAt(screen, s, x, y)
+		return
+	}
+
+	face := faceFor(s)
+	if face == nil {
+		ebitenutil.DebugPrintAt(screen, strings.Map(func(r rune) rune {
+			if r >= utf8.RuneSelf {
+				return '?'
+			}
+			return r
+		}, s), x, y)
+		return
+	}
+	text.Draw(screen, s, face, x, y+face.Metrics().Ascent.Ceil(), color.White)
+}
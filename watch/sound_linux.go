@@ -0,0 +1,35 @@
+//go:build linux
+
+package watch
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Sons de alerta do freedesktop, presentes na maioria das distros
+var systemSounds = []string{
+	"/usr/share/sounds/freedesktop/stereo/dialog-warning.oga",
+	"/usr/share/sounds/freedesktop/stereo/bell.oga",
+}
+
+// PlaySound toca file (ou um som do sistema) com paplay/aplay; sem nenhum
+// dos dois, manda um BEL para o terminal.
+func PlaySound(file string) {
+	files := systemSounds
+	if file != "" {
+		files = append([]string{file}, files...)
+	}
+	for _, player := range []string{"paplay", "aplay"} {
+		path, err := exec.LookPath(player)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if exec.Command(path, f).Run() == nil {
+				return
+			}
+		}
+	}
+	fmt.Print("\a")
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package watch
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32          = windows.NewLazySystemDLL("user32.dll")
+	winmm           = windows.NewLazySystemDLL("winmm.dll")
+	procMessageBeep = user32.NewProc("MessageBeep")
+	procPlaySoundW  = winmm.NewProc("PlaySoundW")
+)
+
+const (
+	mbIconExclamation = 0x30
+	sndFilename       = 0x20000
+	sndAsync          = 0x1
+	sndNoDefault      = 0x2
+)
+
+// PlaySound toca o .wav em file ou, sem arquivo, o som de alerta do sistema.
+func PlaySound(file string) {
+	if file != "" {
+		if p, err := windows.UTF16PtrFromString(file); err == nil {
+			r, _, _ := procPlaySoundW.Call(uintptr(unsafe.Pointer(p)), 0, sndFilename|sndAsync|sndNoDefault)
+			if r != 0 {
+				return
+			}
+		}
+	}
+	procMessageBeep.Call(mbIconExclamation)
+}
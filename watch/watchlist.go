@@ -0,0 +1,245 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"muletinha/entity"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry é um nome (exato) ou padrão glob ("*Ganker*", "Xx?Kill*") a vigiar.
+// Sem Distance/Color usa os padrões da lista.
+type Entry struct {
+	Name     string  `json:"name,omitempty"`
+	Pattern  string  `json:"pattern,omitempty"`
+	Distance float32 `json:"distance,omitempty"`
+	Color    string  `json:"color,omitempty"` // "#RRGGBB"
+	Sound    bool    `json:"sound,omitempty"`
+	Note     string  `json:"note,omitempty"`
+
+	rgba color.RGBA
+}
+
+func (e *Entry) Matches(name string) bool {
+	name = strings.ToLower(name)
+	if e.Name != "" && strings.ToLower(e.Name) == name {
+		return true
+	}
+	if e.Pattern != "" {
+		ok, _ := path.Match(strings.ToLower(e.Pattern), name)
+		return ok
+	}
+	return false
+}
+
+func (e *Entry) RGBA() color.RGBA {
+	return e.rgba
+}
+
+type Alert struct {
+	Time     time.Time
+	Name     string
+	Distance float32
+	Entry    *Entry
+}
+
+// Estado por nome: um alerta ao entrar no raio, outro só depois de sair
+// (com folga) e passar o tempo de rearme
+type alertState struct {
+	inside    bool
+	lastAlert time.Time
+}
+
+// Saída do raio só conta acima de distance * leaveMargin, para quem fica na
+// borda não alertar a cada refresh
+const leaveMargin = 1.2
+
+type Watchlist struct {
+	Enabled         bool    `json:"enabled"`
+	DefaultDistance float32 `json:"default_distance"`
+	DefaultColor    string  `json:"default_color"`
+	RearmSeconds    int     `json:"rearm_seconds"`
+	SoundFile       string  `json:"sound_file,omitempty"` // .wav; vazio = beep do sistema
+	Entries         []Entry `json:"entries"`
+
+	Alerts    []Alert `json:"-"`
+	MaxAlerts int     `json:"-"`
+
+	mutex sync.RWMutex
+	state map[string]*alertState
+}
+
+func NewWatchlist() *Watchlist {
+	wl := &Watchlist{
+		MaxAlerts: 20,
+		state:     make(map[string]*alertState),
+	}
+	wl.setDefaults()
+	wl.LoadFromFile("watchlist.json")
+	return wl
+}
+
+func (wl *Watchlist) setDefaults() {
+	wl.Enabled = true
+	wl.DefaultDistance = 150
+	wl.DefaultColor = "#FF00FF"
+	wl.RearmSeconds = 60
+	wl.SoundFile = ""
+	wl.Entries = nil
+}
+
+func (wl *Watchlist) LoadFromFile(filename string) {
+	defer wl.compile()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		wl.createDefaultFile(filename)
+		return
+	}
+
+	// JSON quebrado pode ter preenchido metade das entradas: volta ao padrão
+	if err := json.Unmarshal(data, wl); err != nil {
+		fmt.Printf("[WATCH] Erro JSON: %v\n", err)
+		wl.setDefaults()
+		return
+	}
+
+	fmt.Printf("[WATCH] Carregadas %d entradas da watchlist\n", len(wl.Entries))
+}
+
+func (wl *Watchlist) createDefaultFile(filename string) {
+	wl.Entries = []Entry{
+		{Name: "ExemploGanker", Distance: 200, Color: "#FF0000", Sound: true, Note: "troque pelos nomes reais"},
+		{Pattern: "*Assassin*"},
+	}
+
+	data, _ := json.MarshalIndent(wl, "", "  ")
+	os.WriteFile(filename, data, 0644)
+	fmt.Printf("[WATCH] Criado arquivo %s\n", filename)
+}
+
+func (wl *Watchlist) compile() {
+	def := parseColor(wl.DefaultColor, color.RGBA{255, 0, 255, 255})
+	for i := range wl.Entries {
+		e := &wl.Entries[i]
+		e.rgba = parseColor(e.Color, def)
+		if e.Distance <= 0 {
+			e.Distance = wl.DefaultDistance
+		}
+	}
+}
+
+func parseColor(s string, def color.RGBA) color.RGBA {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return def
+	}
+	return color.RGBA{r, g, b, 255}
+}
+
+// Match returns the first entry that matches name.
+func (wl *Watchlist) Match(name string) (*Entry, bool) {
+	for i := range wl.Entries {
+		if wl.Entries[i].Matches(name) {
+			return &wl.Entries[i], true
+		}
+	}
+	return nil, false
+}
+
+// Update evaluates the tracked entities and returns the new alerts. An
+// entity alerts once when it enters its entry's distance and again only
+// after leaving and waiting RearmSeconds.
+func (wl *Watchlist) Update(entities []entity.Tracked, now time.Time) []Alert {
+	if !wl.Enabled {
+		return nil
+	}
+
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+
+	rearm := time.Duration(wl.RearmSeconds) * time.Second
+	seen := make(map[string]bool)
+	var alerts []Alert
+
+	for _, e := range entities {
+		entry, ok := wl.Match(e.Name)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(e.Name)
+		seen[key] = true
+
+		st := wl.state[key]
+		if st == nil {
+			st = &alertState{}
+			wl.state[key] = st
+		}
+
+		switch {
+		case !st.inside && e.Distance <= entry.Distance:
+			st.inside = true
+			if now.Sub(st.lastAlert) < rearm {
+				continue
+			}
+			st.lastAlert = now
+			a := Alert{Time: now, Name: e.Name, Distance: e.Distance, Entry: entry}
+			alerts = append(alerts, a)
+			wl.addAlert(a)
+		case st.inside && e.Distance > entry.Distance*leaveMargin:
+			st.inside = false
+		}
+	}
+
+	// Quem sumiu do tracker saiu do raio
+	for key, st := range wl.state {
+		if !seen[key] {
+			st.inside = false
+		}
+	}
+
+	for _, a := range alerts {
+		if a.Entry.Sound {
+			go PlaySound(wl.SoundFile)
+			break
+		}
+	}
+	return alerts
+}
+
+func (wl *Watchlist) addAlert(a Alert) {
+	wl.Alerts = append(wl.Alerts, a)
+	if len(wl.Alerts) > wl.MaxAlerts {
+		copy(wl.Alerts, wl.Alerts[1:])
+		wl.Alerts = wl.Alerts[:wl.MaxAlerts]
+	}
+}
+
+// Highlight reports whether name is a watched entity currently inside its
+// alert distance, and the color to mark it with.
+func (wl *Watchlist) Highlight(name string) (color.RGBA, bool) {
+	wl.mutex.RLock()
+	defer wl.mutex.RUnlock()
+
+	st := wl.state[strings.ToLower(name)]
+	if st == nil || !st.inside {
+		return color.RGBA{}, false
+	}
+	entry, ok := wl.Match(name)
+	if !ok {
+		return color.RGBA{}, false
+	}
+	return entry.rgba, true
+}
+
+// RecentAlerts returns a copy of the alert history for the events panel.
+func (wl *Watchlist) RecentAlerts() []Alert {
+	wl.mutex.RLock()
+	defer wl.mutex.RUnlock()
+	return append([]Alert(nil), wl.Alerts...)
+}